@@ -9,37 +9,104 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var addCmd = &cobra.Command{
-	Use:   "add",
-	Short: "Add a source",
-	Run: func(cmd *cobra.Command, args []string) {
-		c, err := config.Load()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "error loading config:", err)
-			return
-		}
-
-		dir := filepath.Dir(c.Location)
-		if err := os.Chdir(dir); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
-			return
-		}
-
-		for _, source := range args {
-			if err := c.Add(source); err != nil {
-				fmt.Fprintf(os.Stderr, "error adding source %s: %v\n", source, err)
-			}
-		}
-
-		if err := c.Save(); err != nil {
-			fmt.Fprintln(os.Stderr, "error saving config:", err)
-			return
-		}
-
-		c.Sync()
-	},
-}
+var (
+	addName   string
+	addDest   string
+	addCommit string
+	addNoSync bool
+	addPick   bool
+
+	addCmd = &cobra.Command{
+		Use:   "add <url>[@<ref>]...",
+		Short: "Add a source",
+		Long: `Add a source.
+The reference is checked against the remote repository.
+If no reference is given, the latest version tag is used.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 1 && (addName != "" || addDest != "" || addCommit != "") {
+				fmt.Fprintln(os.Stderr, "--name, --dest and --commit can only be used with a single source")
+				os.Exit(1)
+			}
+
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				return
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				return
+			}
+
+			failed := false
+			added := 0
+			for _, arg := range args {
+				source := c.ParseSource(arg)
+				if addCommit != "" {
+					source.ReferenceName = addCommit
+				}
+				if addPick {
+					refs, err := config.ListRemote(source.CanonicalUrl())
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "error adding source %s: %v\n", arg, err)
+						failed = true
+						continue
+					}
+					ref, err := pickReference(refs, source.Url, source.ReferenceName)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "error adding source %s: %v\n", arg, err)
+						failed = true
+						continue
+					}
+					source.ReferenceName = ref
+				}
+				source.LinkName = addName
+				source.Dest = addDest
+
+				kind, err := source.Validate()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error adding source %s: %v\n", arg, err)
+					failed = true
+					continue
+				}
+				if err := c.AddSource(source); err != nil {
+					fmt.Fprintf(os.Stderr, "error adding source %s: %v\n", arg, err)
+					failed = true
+					continue
+				}
+				fmt.Printf("added %s@%s (%s)\n", source.Url, source.ReferenceName, kind)
+				added++
+			}
+			if added == 0 {
+				os.Exit(1)
+			}
+
+			if err := c.Save(); err != nil {
+				fmt.Fprintln(os.Stderr, "error saving config:", err)
+				return
+			}
+
+			if !addNoSync {
+				if err := c.Sync(); err != nil {
+					fmt.Fprintln(os.Stderr, "error syncing sources:", err)
+					os.Exit(1)
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+)
 
 func init() {
+	addCmd.Flags().StringVar(&addName, "name", "", "Name of the link in the vendored directory")
+	addCmd.Flags().StringVar(&addDest, "dest", "", "Path of the link, relative to the project directory")
+	addCmd.Flags().StringVar(&addCommit, "commit", "", "Pin the source to a commit")
+	addCmd.Flags().BoolVarP(&addPick, "interactive", "i", false, "Pick the reference from a list of tags and branches")
+	addCmd.Flags().BoolVar(&addNoSync, "no-sync", false, "Don't sync sources after adding")
 	rootCmd.AddCommand(addCmd)
 }
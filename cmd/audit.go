@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"vend/internal/audit"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditDatabase string
+	auditSeverity string
+
+	auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Check all sources against a local vulnerability advisory database",
+		Run: func(cmd *cobra.Command, args []string) {
+			threshold, err := audit.ParseSeverity(auditSeverity)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			db, err := audit.LoadDatabase(auditDatabase)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading advisory database:", err)
+				os.Exit(1)
+			}
+
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			lock, err := c.LoadLock()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading lock:", err)
+				os.Exit(1)
+			}
+
+			sources, err := c.Resolved()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading lock:", err)
+				os.Exit(1)
+			}
+
+			var findings []audit.Finding
+			for _, source := range sources {
+				locked, _ := lock.Find(source)
+				f, err := db.Check(source, locked)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error checking %s: %v\n", source.Url, err)
+					os.Exit(1)
+				}
+				findings = append(findings, f...)
+			}
+
+			if len(findings) == 0 {
+				fmt.Printf("no known vulnerabilities found in %d sources\n", len(sources))
+				return
+			}
+
+			failed, unverified := false, 0
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "SOURCE\tREF\tADVISORY\tSEVERITY\tFIXED\tSUMMARY")
+			for _, f := range findings {
+				fixed := strings.Join(f.Fixed, ", ")
+				if fixed == "" {
+					fixed = "-"
+				}
+				severity := f.Severity.String()
+				if f.Unverified {
+					severity += " (unverified)"
+					unverified++
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", f.Source.ShortName(), f.Source.ReferenceName, f.Advisory.ID, severity, fixed, f.Advisory.Summary)
+				// findings without severity information or complete history always fail the audit
+				if f.Unverified || f.Severity == audit.SeverityUnknown || f.Severity >= threshold {
+					failed = true
+				}
+			}
+			tw.Flush()
+			if unverified != 0 {
+				fmt.Printf("\n%d advisories could not be evaluated without the full history, run vend deepen <source>\n", unverified)
+			}
+
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	auditCmd.Flags().StringVar(&auditDatabase, "db", "", "OSV advisory file or directory")
+	auditCmd.Flags().StringVar(&auditSeverity, "severity", "low", "Minimum severity (low, medium, high, critical) that fails the audit")
+	_ = auditCmd.MarkFlagRequired("db")
+	rootCmd.AddCommand(auditCmd)
+}
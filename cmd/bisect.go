@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	bisectGood string
+	bisectBad  string
+	bisectRun  string
+
+	bisectCmd = &cobra.Command{
+		Use:   "bisect <source> --good <ref> --bad <ref> --run <script>",
+		Short: "Find the commit of a source that broke the project",
+		Long: `Binary search the commits of a source between a good and a bad reference.
+Every candidate is linked into vendored/ and the script is run: exit code 0 marks
+the commit as good, 125 skips it and any other code marks it as bad.
+The original link is restored and the downloaded candidates are deleted at the
+end, also when the search is interrupted.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			source, err := c.Find(args[0])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			first, err := c.Bisect(source, bisectGood, bisectBad, bisectRun, func(step config.BisectStep) {
+				subject, _, _ := strings.Cut(step.Commit.Message, "\n")
+				fmt.Printf("%-4s %s %s (%d left)\n", step.Result, shortHash(step.Commit.Hash.String()), subject, step.Remaining)
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error bisecting:", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("\nfirst bad commit: %s\n", first.Hash)
+			fmt.Printf("Author: %s <%s>\n", first.Author.Name, first.Author.Email)
+			fmt.Printf("Date:   %s\n\n", first.Author.When.Format("2006-01-02 15:04:05 -0700"))
+			fmt.Println(indent(strings.TrimSpace(first.Message)))
+		},
+	}
+)
+
+func init() {
+	bisectCmd.Flags().StringVar(&bisectGood, "good", "", "Reference known to work")
+	bisectCmd.Flags().StringVar(&bisectBad, "bad", "", "Reference known to be broken")
+	bisectCmd.Flags().StringVar(&bisectRun, "run", "", "Script from vend.yaml that tests the project")
+	_ = bisectCmd.MarkFlagRequired("good")
+	_ = bisectCmd.MarkFlagRequired("bad")
+	_ = bisectCmd.MarkFlagRequired("run")
+	rootCmd.AddCommand(bisectCmd)
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	changesMarkdown bool
+	changesLog      bool
+
+	changesCmd = &cobra.Command{
+		Use:   "changes <from>[..<to>]",
+		Short: "Summarize the changes of the sources between two revisions of the project",
+		Long: `Summarize which sources were added, removed or upgraded between two revisions
+of the git repository of the project. <to> defaults to HEAD.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			if strings.Contains(args[0], "...") {
+				fmt.Fprintln(os.Stderr, "symmetric ranges (a...b) are not supported, use <from>..<to>")
+				os.Exit(1)
+			}
+			from, to, ok := strings.Cut(args[0], "..")
+			if !ok || to == "" {
+				to = "HEAD"
+			}
+			if from == "" {
+				fmt.Fprintln(os.Stderr, "missing <from> revision")
+				os.Exit(1)
+			}
+
+			changes, err := c.Changes(from, to)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error comparing revisions:", err)
+				os.Exit(1)
+			}
+
+			logs := make(map[int][]config.LogEntry)
+			if changesLog {
+				for i, change := range changes {
+					if change.Kind != config.SourceUpgraded {
+						continue
+					}
+					log, err := upstreamLog(change)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "error reading upstream log of %s: %v\n", change.Source.ShortName(), err)
+						continue
+					}
+					logs[i] = log
+				}
+			}
+
+			if changesMarkdown {
+				writeChangesMarkdown(os.Stdout, args[0], changes, logs)
+			} else {
+				writeChangesText(os.Stdout, changes, logs)
+			}
+		},
+	}
+)
+
+// upstreamLog reads the commits between the old and new state of an upgraded source from its mirror.
+func upstreamLog(change config.SourceChange) ([]config.LogEntry, error) {
+	oldRev, newRev := change.OldCommit, change.NewCommit
+	if oldRev == "" {
+		oldRev = change.OldRef
+	}
+	if newRev == "" {
+		newRev = change.NewRef
+	}
+	repo, err := change.Source.FetchRefs(nil, oldRev, newRev)
+	if err != nil {
+		return nil, err
+	}
+	oldCommit, err := config.ResolveCommit(repo, oldRev)
+	if err != nil {
+		return nil, err
+	}
+	newCommit, err := config.ResolveCommit(repo, newRev)
+	if err != nil {
+		return nil, err
+	}
+	return config.Log(repo, oldCommit, newCommit, nil)
+}
+
+func changeRefs(change config.SourceChange) (string, string) {
+	oldRef, newRef := change.OldRef, change.NewRef
+	if oldRef == newRef {
+		oldRef += " (" + shortHash(change.OldCommit) + ")"
+		newRef += " (" + shortHash(change.NewCommit) + ")"
+	}
+	return oldRef, newRef
+}
+
+func writeChangesText(w io.Writer, changes []config.SourceChange, logs map[int][]config.LogEntry) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+	for i, change := range changes {
+		switch change.Kind {
+		case config.SourceAdded:
+			fmt.Fprintf(w, "+ %s %s\n", change.Source.ShortName(), change.NewRef)
+		case config.SourceRemoved:
+			fmt.Fprintf(w, "- %s %s\n", change.Source.ShortName(), change.OldRef)
+		case config.SourceUpgraded:
+			oldRef, newRef := changeRefs(change)
+			fmt.Fprintf(w, "~ %s %s -> %s\n", change.Source.ShortName(), oldRef, newRef)
+			for _, entry := range logs[i] {
+				fmt.Fprintf(w, "    %s %s\n", shortHash(entry.Hash), entry.Subject)
+			}
+		}
+	}
+}
+
+func writeChangesMarkdown(w io.Writer, revRange string, changes []config.SourceChange, logs map[int][]config.LogEntry) {
+	fmt.Fprintf(w, "## Dependency changes (%s)\n", revRange)
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "\nNo changes.")
+		return
+	}
+	sections := []struct {
+		kind  config.ChangeKind
+		title string
+	}{
+		{config.SourceAdded, "Added"},
+		{config.SourceRemoved, "Removed"},
+		{config.SourceUpgraded, "Upgraded"},
+	}
+	for _, section := range sections {
+		header := false
+		for i, change := range changes {
+			if change.Kind != section.kind {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(w, "\n### %s\n\n", section.title)
+				header = true
+			}
+			name := fmt.Sprintf("[%s](%s)", change.Source.ShortName(), change.Source.CanonicalUrl())
+			switch change.Kind {
+			case config.SourceAdded:
+				fmt.Fprintf(w, "- %s `%s`\n", name, change.NewRef)
+			case config.SourceRemoved:
+				fmt.Fprintf(w, "- %s `%s`\n", name, change.OldRef)
+			case config.SourceUpgraded:
+				oldRef, newRef := changeRefs(change)
+				fmt.Fprintf(w, "- %s `%s` → `%s`\n", name, oldRef, newRef)
+				for _, entry := range logs[i] {
+					fmt.Fprintf(w, "  - `%s` %s\n", shortHash(entry.Hash), entry.Subject)
+				}
+			}
+		}
+	}
+}
+
+func init() {
+	changesCmd.Flags().BoolVar(&changesMarkdown, "markdown", false, "Print as Markdown")
+	changesCmd.Flags().BoolVar(&changesLog, "log", false, "Include the upstream commit log of upgraded sources")
+	rootCmd.AddCommand(changesCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check all sources against the policies of the project",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading config:", err)
+			os.Exit(1)
+		}
+
+		dir := filepath.Dir(c.Location)
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+
+		if err := c.CheckLicenses(); err != nil {
+			fmt.Fprintln(os.Stderr, "license policy violated:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
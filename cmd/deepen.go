@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	deepenDepth int
+
+	deepenCmd = &cobra.Command{
+		Use:   "deepen <source>",
+		Short: "Download more history of a shallow source",
+		Long: `Download more history of a shallow source at its current commit and set its
+depth in vend.yaml. Without --depth the full history is downloaded.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			source, err := c.Deepen(args[0], deepenDepth)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error deepening source:", err)
+				os.Exit(1)
+			}
+			if err := c.Save(); err != nil {
+				fmt.Fprintln(os.Stderr, "error saving config:", err)
+				os.Exit(1)
+			}
+
+			if source.CloneDepth() == 0 {
+				fmt.Printf("%s now has the full history\n", source.ShortName())
+			} else {
+				fmt.Printf("%s now has a depth of %d\n", source.ShortName(), source.CloneDepth())
+			}
+		},
+	}
+)
+
+func init() {
+	deepenCmd.Flags().IntVar(&deepenDepth, "depth", 0, "Number of commits to download, 0 for the full history")
+	rootCmd.AddCommand(deepenCmd)
+}
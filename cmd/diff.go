@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffPatch bool
+	diffPaths []string
+
+	diffCmd = &cobra.Command{
+		Use:   "diff <source> [<old-ref>] <new-ref>",
+		Short: "Show the changes of a source between two references",
+		Long: `Show the commit log and a diffstat of a source between two references.
+If old-ref is omitted, the locked commit is used.`,
+		Args: cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			source, err := c.Find(args[0])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			var oldRef, newRef string
+			if len(args) == 3 {
+				oldRef, newRef = args[1], args[2]
+			} else {
+				newRef = args[1]
+				lock, err := c.LoadLock()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "error loading lock:", err)
+					os.Exit(1)
+				}
+				if locked, ok := lock.Find(source); ok {
+					oldRef = locked.Commit
+				} else {
+					oldRef = source.ReferenceName
+				}
+			}
+
+			repo, err := source.FetchRefs(os.Stderr, oldRef, newRef)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			oldCommit, err := config.ResolveCommit(repo, oldRef)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			newCommit, err := config.ResolveCommit(repo, newRef)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			oldCommit, newCommit, err = source.FetchBlobs(repo, oldCommit, newCommit, diffPaths)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			log, err := config.Log(repo, oldCommit, newCommit, diffPaths)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error reading commit log:", err)
+				os.Exit(1)
+			}
+			patch, err := config.Diff(oldCommit, newCommit, diffPaths)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("%s %s (%s) -> %s (%s)\n\n", source.ShortName(), oldRef, shortHash(oldCommit.Hash.String()), newRef, shortHash(newCommit.Hash.String()))
+			fmt.Printf("%d commits\n", len(log))
+			for _, entry := range log {
+				fmt.Printf("  %s %s %-20s %s\n", shortHash(entry.Hash), entry.Date.Format(time.DateOnly), entry.Author, entry.Subject)
+			}
+
+			fmt.Println()
+			added, deleted := 0, 0
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			for _, stat := range patch.Stats {
+				fmt.Fprintf(tw, " %s\t| %d\t%s\n", stat.Name, stat.Addition+stat.Deletion, diffBar(stat.Addition, stat.Deletion))
+				added += stat.Addition
+				deleted += stat.Deletion
+			}
+			tw.Flush()
+			fmt.Printf(" %d files changed, %d insertions(+), %d deletions(-)\n", len(patch.Stats), added, deleted)
+
+			if diffPatch {
+				fmt.Println()
+				if err := patch.Encode(os.Stdout); err != nil {
+					fmt.Fprintln(os.Stderr, "error writing diff:", err)
+					os.Exit(1)
+				}
+			}
+		},
+	}
+)
+
+// diffBar renders the additions and deletions like git diff --stat, scaled to at most 40 characters.
+func diffBar(added, deleted int) string {
+	const width = 40
+	if total := added + deleted; total > width {
+		added = added * width / total
+		deleted = deleted * width / total
+	}
+	return strings.Repeat("+", added) + strings.Repeat("-", deleted)
+}
+
+func init() {
+	diffCmd.Flags().BoolVarP(&diffPatch, "patch", "p", false, "Print the full diff")
+	diffCmd.Flags().StringArrayVar(&diffPaths, "path", nil, "Only show changes of files matching this glob (can be repeated)")
+	rootCmd.AddCommand(diffCmd)
+}
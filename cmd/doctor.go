@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+	"vend/internal/doctor"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorFix bool
+
+	doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with the environment and the project",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "no project found, only checking the environment:", err)
+				c = nil
+			} else {
+				dir := filepath.Dir(c.Location)
+				if err := os.Chdir(dir); err != nil {
+					fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+					os.Exit(1)
+				}
+			}
+
+			remaining := 0
+			needsSync := false
+			for _, result := range doctor.Run(c) {
+				mark := "✓"
+				if len(result.Problems) != 0 {
+					mark = "✗"
+				}
+				if result.Info != "" {
+					fmt.Printf("%s %s: %s\n", mark, result.Name, result.Info)
+				} else {
+					fmt.Printf("%s %s\n", mark, result.Name)
+				}
+
+				for _, problem := range result.Problems {
+					fmt.Printf("    %s\n", problem.Message)
+					if !doctorFix || (problem.Repair == nil && !problem.NeedsSync) {
+						if problem.Fix != "" {
+							fmt.Printf("      fix: %s\n", problem.Fix)
+						}
+						remaining++
+						continue
+					}
+					if problem.Repair != nil {
+						if err := problem.Repair(); err != nil {
+							fmt.Printf("      repair failed: %v\n", err)
+							remaining++
+							continue
+						}
+						fmt.Println("      repaired")
+					}
+					needsSync = needsSync || problem.NeedsSync
+				}
+			}
+
+			if needsSync && c != nil {
+				fmt.Println("running vend sync")
+				if err := c.Sync(); err != nil {
+					fmt.Fprintln(os.Stderr, "error syncing sources:", err)
+					os.Exit(1)
+				}
+			}
+
+			if remaining != 0 {
+				if !doctorFix {
+					fmt.Printf("\n%d problems found, vend doctor --fix repairs what it safely can\n", remaining)
+				} else {
+					fmt.Printf("\n%d problems need to be fixed manually\n", remaining)
+				}
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the problems that can be fixed safely")
+	rootCmd.AddCommand(doctorCmd)
+}
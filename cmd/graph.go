@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+	"vend/internal/graph"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat string
+
+	graphCmd = &cobra.Command{
+		Use:   "graph",
+		Short: "Print the dependency graph of the sources, their transitive sources and submodules",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			g := loadGraph()
+
+			var err error
+			switch graphFormat {
+			case "dot":
+				err = g.WriteDot(os.Stdout)
+			case "mermaid":
+				err = g.WriteMermaid(os.Stdout)
+			case "json":
+				err = g.WriteJSON(os.Stdout)
+			default:
+				err = fmt.Errorf("unknown format %s, expected dot, mermaid or json", graphFormat)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error writing graph:", err)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func loadGraph() *graph.Graph {
+	c, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading config:", err)
+		os.Exit(1)
+	}
+
+	dir := filepath.Dir(c.Location)
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+
+	g, err := graph.FromConfig(c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error building graph:", err)
+		os.Exit(1)
+	}
+	return g
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Output format (dot, mermaid or json)")
+	rootCmd.AddCommand(graphCmd)
+}
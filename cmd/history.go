@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the states recorded by previous syncs",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading config:", err)
+			os.Exit(1)
+		}
+
+		dir := filepath.Dir(c.Location)
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+
+		h, err := c.LoadHistory()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading history:", err)
+			os.Exit(1)
+		}
+		if len(h.Entries) == 0 {
+			fmt.Println("no history yet, run vend sync")
+			return
+		}
+		for n := range len(h.Entries) {
+			entry, _ := h.Entry(n)
+			fmt.Printf("%d  %s  config %s\n", n, entry.Time.Local().Format("2006-01-02 15:04:05"), shortHash(entry.ConfigDigest[len("sha256:"):]))
+			for _, source := range entry.Sources {
+				fmt.Printf("     %s@%s  %s\n", source.Url, source.ReferenceName, shortHash(source.Commit))
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
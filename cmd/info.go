@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"vend/internal/config"
+	"vend/internal/license"
+	"vend/internal/units"
+
+	"github.com/spf13/cobra"
+)
+
+type (
+	infoEntry struct {
+		listEntry
+		CommitDate    *time.Time      `json:"commit_date,omitempty"`
+		Author        string          `json:"author,omitempty"`
+		CommitMessage string          `json:"commit_message,omitempty"`
+		TagMessage    string          `json:"tag_message,omitempty"`
+		License       string          `json:"license"`
+		Submodules    []infoSubmodule `json:"submodules"`
+		Summary       string          `json:"summary,omitempty"`
+	}
+
+	infoSubmodule struct {
+		Path       string          `json:"path"`
+		Url        string          `json:"url"`
+		Commit     string          `json:"commit,omitempty"`
+		Submodules []infoSubmodule `json:"submodules,omitempty"`
+	}
+)
+
+var (
+	infoJson bool
+
+	infoCmd = &cobra.Command{
+		Use:   "info <source>",
+		Short: "Show details about a source",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			source, err := c.Find(args[0])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			lock, err := c.LoadLock()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading lock:", err)
+				os.Exit(1)
+			}
+			locked, _ := lock.Find(source)
+			size, _ := config.DirSize(source.DestPath())
+
+			entry := infoEntry{
+				listEntry: listEntry{
+					Name:          source.ShortName(),
+					Url:           source.CanonicalUrl(),
+					ReferenceName: source.ReferenceName,
+					Commit:        locked.Commit,
+					Size:          size,
+					Link:          filepath.ToSlash(source.LinkPath()),
+					Store:         source.DestPath(),
+				},
+				License:    license.Unknown,
+				Submodules: []infoSubmodule{},
+				Summary:    source.ReadmeSummary(),
+			}
+			if commit, err := source.Commit(); err == nil {
+				entry.Commit = commit.Hash
+				entry.CommitDate = &commit.Date
+				entry.Author = fmt.Sprintf("%s <%s>", commit.Author, commit.AuthorEmail)
+				entry.CommitMessage = commit.Message
+				entry.TagMessage = commit.TagMessage
+			} else {
+				fmt.Fprintln(os.Stderr, "store entry not available, run vend sync:", err)
+			}
+			if licenses, err := source.Licenses(); err == nil {
+				entry.License = license.Expression(licenses)
+			}
+			if sms, err := source.Submodules(); err == nil {
+				entry.Submodules = infoSubmodules(sms, source.DestPath())
+			}
+
+			if infoJson {
+				printJson(entry)
+				return
+			}
+
+			fmt.Printf("Name:       %s\n", entry.Name)
+			fmt.Printf("URL:        %s\n", entry.Url)
+			fmt.Printf("Reference:  %s\n", entry.ReferenceName)
+			fmt.Printf("Commit:     %s\n", entry.Commit)
+			if entry.CommitDate != nil {
+				fmt.Printf("Date:       %s\n", entry.CommitDate.Format(time.RFC1123Z))
+				fmt.Printf("Author:     %s\n", entry.Author)
+			}
+			fmt.Printf("License:    %s\n", entry.License)
+			fmt.Printf("Size:       %s\n", units.FormatBytes(entry.Size))
+			fmt.Printf("Link:       %s\n", entry.Link)
+			fmt.Printf("Store:      %s\n", entry.Store)
+			if entry.TagMessage != "" {
+				fmt.Printf("\nTag message:\n%s\n", indent(entry.TagMessage))
+			}
+			if len(entry.Submodules) != 0 {
+				fmt.Println("\nSubmodules:")
+				printSubmodules(entry.Submodules, "  ")
+			}
+			if entry.Summary != "" {
+				fmt.Printf("\n%s\n", entry.Summary)
+			}
+		},
+	}
+)
+
+func infoSubmodules(sms []config.Submodule, root string) []infoSubmodule {
+	result := make([]infoSubmodule, 0, len(sms))
+	for _, sm := range sms {
+		rel, err := filepath.Rel(root, sm.Path)
+		if err != nil {
+			rel = sm.Path
+		}
+		result = append(result, infoSubmodule{
+			Path:       filepath.ToSlash(rel),
+			Url:        sm.Url,
+			Commit:     sm.Commit,
+			Submodules: infoSubmodules(sm.Submodules, root),
+		})
+	}
+	return result
+}
+
+func printSubmodules(sms []infoSubmodule, prefix string) {
+	for _, sm := range sms {
+		fmt.Printf("%s%s  %s  %s\n", prefix, sm.Path, sm.Url, shortHash(sm.Commit))
+		printSubmodules(sm.Submodules, prefix+"  ")
+	}
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
+
+func init() {
+	infoCmd.Flags().BoolVar(&infoJson, "json", false, "Print as JSON")
+	rootCmd.AddCommand(infoCmd)
+}
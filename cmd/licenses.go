@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	licensesNotice bool
+
+	licensesCmd = &cobra.Command{
+		Use:   "licenses",
+		Short: "List the licenses of all sources",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			sources, err := c.Resolved()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading lock:", err)
+				os.Exit(1)
+			}
+
+			if licensesNotice {
+				if err := writeNotice(os.Stdout, sources); err != nil {
+					fmt.Fprintln(os.Stderr, "error writing notice:", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "SOURCE\tLICENSE\tFILE")
+			failed := false
+			for _, source := range sources {
+				licenses, err := source.Licenses()
+				if err != nil {
+					fmt.Fprintf(tw, "%s\t%s\t%s\n", source.ShortName(), "-", "store entry missing")
+					failed = true
+					continue
+				}
+				if len(licenses) == 0 {
+					fmt.Fprintf(tw, "%s\t%s\t%s\n", source.ShortName(), "-", "no license file found")
+					continue
+				}
+				for _, l := range licenses {
+					fmt.Fprintf(tw, "%s\t%s\t%s\n", source.ShortName(), l.ID, l.File)
+				}
+			}
+			tw.Flush()
+
+			if err := c.CheckLicenses(); err != nil {
+				fmt.Fprintln(os.Stderr, "license policy violated:", err)
+				failed = true
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+// writeNotice writes the license texts of the sources, including the transitive ones, into one third-party notice.
+func writeNotice(w io.Writer, sources []config.Source) error {
+	fmt.Fprintln(w, "THIRD-PARTY SOFTWARE NOTICES")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "This software includes the following third-party components.")
+	for _, source := range sources {
+		licenses, err := source.Licenses()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, strings.Repeat("=", 80))
+		fmt.Fprintf(w, "%s %s\n%s\n", source.ShortName(), source.ReferenceName, source.Url)
+		fmt.Fprintln(w, strings.Repeat("=", 80))
+		if len(licenses) == 0 {
+			fmt.Fprintln(w, "No license file found.")
+			continue
+		}
+		for _, l := range licenses {
+			b, err := os.ReadFile(filepath.Join(source.DestPath(), l.File))
+			if err != nil {
+				return fmt.Errorf("failed to read %s of %s: %w", l.File, source.Url, err)
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, strings.TrimSpace(string(b)))
+		}
+	}
+	return nil
+}
+
+func init() {
+	licensesCmd.Flags().BoolVar(&licensesNotice, "notice", false, "Print a combined third-party NOTICE file")
+	rootCmd.AddCommand(licensesCmd)
+}
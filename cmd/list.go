@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"vend/internal/config"
+	"vend/internal/units"
+
+	"github.com/spf13/cobra"
+)
+
+type listEntry struct {
+	Name          string `json:"name"`
+	Url           string `json:"url"`
+	ReferenceName string `json:"reference_name"`
+	Commit        string `json:"commit,omitempty"`
+	Size          uint64 `json:"size"`
+	Link          string `json:"link"`
+	Store         string `json:"store"`
+}
+
+var (
+	listJson bool
+
+	listCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all sources",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			lock, err := c.LoadLock()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading lock:", err)
+				os.Exit(1)
+			}
+			sources, err := c.Resolved()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading lock:", err)
+				os.Exit(1)
+			}
+
+			entries := make([]listEntry, 0, len(sources))
+			for _, source := range sources {
+				locked, _ := lock.Find(source)
+				size, _ := config.DirSize(source.DestPath())
+				entries = append(entries, listEntry{
+					Name:          source.ShortName(),
+					Url:           source.CanonicalUrl(),
+					ReferenceName: source.ReferenceName,
+					Commit:        locked.Commit,
+					Size:          size,
+					Link:          filepath.ToSlash(source.LinkPath()),
+					Store:         source.DestPath(),
+				})
+			}
+
+			if listJson {
+				printJson(entries)
+				return
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tURL\tREF\tCOMMIT\tSIZE\tLINK")
+			for _, e := range entries {
+				commit := "-"
+				if e.Commit != "" {
+					commit = shortHash(e.Commit)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Name, e.Url, e.ReferenceName, commit, units.FormatBytes(e.Size), e.Link)
+			}
+			tw.Flush()
+		},
+	}
+)
+
+func printJson(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "error encoding json:", err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listJson, "json", false, "Print as JSON")
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"vend/internal/config"
+	"vend/internal/picker"
+	"vend/internal/semver"
+)
+
+// pickReference lets the user pick a tag or branch of the remote.
+// Tags are sorted from the highest to the lowest version, branches follow with the default branch first.
+func pickReference(refs *config.RemoteRefs, title string, current string) (string, error) {
+	current = strings.TrimPrefix(strings.TrimPrefix(current, "refs/tags/"), "refs/heads/")
+
+	tags := refs.Tags()
+	semver.SortDescending(tags)
+	branches := refs.Branches()
+	if def := refs.DefaultBranch(); def != "" {
+		if i := slices.Index(branches, def); i > 0 {
+			branches = append([]string{def}, slices.Delete(branches, i, i+1)...)
+		}
+	}
+
+	items := make([]picker.Item, 0, len(tags)+len(branches))
+	for _, tag := range tags {
+		items = append(items, picker.Item{Name: tag, Kind: "tag", Current: tag == current})
+	}
+	for _, branch := range branches {
+		items = append(items, picker.Item{Name: branch, Kind: "branch", Current: branch == current})
+	}
+	return picker.Pick(title, items, refs.CommitDates)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	removePurge bool
+
+	removeCmd = &cobra.Command{
+		Use:     "remove <source...>",
+		Aliases: []string{"rm"},
+		Short:   "Remove sources",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			lock, err := c.LoadLock()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading lock:", err)
+				os.Exit(1)
+			}
+
+			failed := false
+			removed := make([]config.Source, 0, len(args))
+			for _, arg := range args {
+				source, err := c.Find(arg)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error removing source %s: %v\n", arg, err)
+					failed = true
+					continue
+				}
+				_ = c.Remove(source.Url)
+				lock.Remove(source)
+				removed = append(removed, source)
+
+				link := source.LinkPath()
+				if err := os.Remove(link); err != nil && !errors.Is(err, os.ErrNotExist) {
+					fmt.Fprintf(os.Stderr, "error removing link %s: %v\n", link, err)
+					failed = true
+				}
+			}
+
+			if err := c.Save(); err != nil {
+				fmt.Fprintln(os.Stderr, "error saving config:", err)
+				os.Exit(1)
+			}
+			if err := c.SaveLock(lock); err != nil {
+				fmt.Fprintln(os.Stderr, "error saving lock:", err)
+				os.Exit(1)
+			}
+
+			if removePurge {
+				for _, source := range removed {
+					dest := source.DestPath()
+					referenced, err := c.IsReferenced(dest)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, "error reading projects:", err)
+						os.Exit(1)
+					}
+					if referenced {
+						fmt.Printf("keeping %s, it is still used by a project\n", dest)
+						continue
+					}
+					if err := source.Purge(); err != nil {
+						fmt.Fprintf(os.Stderr, "error purging %s: %v\n", dest, err)
+						failed = true
+					}
+				}
+			}
+
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	removeCmd.Flags().BoolVar(&removePurge, "purge", false, "Delete the store entry if no other project uses it")
+	rootCmd.AddCommand(removeCmd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [n]",
+	Short: "Restore the state of a previous sync",
+	Long: `Restore vend.yaml, vend.lock and the links in vendored/ to the state of entry n
+of vend history. n defaults to 1, the sync before the current one.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		n := 1
+		if len(args) == 1 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil {
+				fmt.Fprintln(os.Stderr, "error parsing history entry:", err)
+				os.Exit(1)
+			}
+		}
+
+		c, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading config:", err)
+			os.Exit(1)
+		}
+
+		dir := filepath.Dir(c.Location)
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+
+		h, err := c.LoadHistory()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading history:", err)
+			os.Exit(1)
+		}
+		entry, err := h.Entry(n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+
+		if _, err := c.Rollback(entry); err != nil {
+			fmt.Fprintln(os.Stderr, "error rolling back:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("restored the state of %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"vend/internal/network"
 
 	"github.com/spf13/cobra"
 )
@@ -9,6 +10,9 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "vend",
 	Short: "Manage external sources easily",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		network.Setup()
+	},
 }
 
 func Execute() {
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+	"vend/internal/sbom"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	sbomFormat string
+
+	sbomCmd = &cobra.Command{
+		Use:   "sbom",
+		Short: "Export a software bill of materials of all sources",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := config.Load()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error loading config:", err)
+				os.Exit(1)
+			}
+
+			dir := filepath.Dir(c.Location)
+			if err := os.Chdir(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+				os.Exit(1)
+			}
+
+			doc, err := sbom.FromConfig(c)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error collecting components:", err)
+				os.Exit(1)
+			}
+
+			switch sbomFormat {
+			case "cyclonedx-json":
+				err = doc.WriteCycloneDX(os.Stdout)
+			case "spdx-json":
+				err = doc.WriteSPDX(os.Stdout)
+			default:
+				err = fmt.Errorf("unknown format %s, expected cyclonedx-json or spdx-json", sbomFormat)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error writing SBOM:", err)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	sbomCmd.Flags().StringVar(&sbomFormat, "format", "cyclonedx-json", "Output format (cyclonedx-json or spdx-json)")
+	rootCmd.AddCommand(sbomCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"vend/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether config, lock, store and vendored links are in sync",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading config:", err)
+			os.Exit(1)
+		}
+
+		dir := filepath.Dir(c.Location)
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+
+		statuses, strays, err := c.Status()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error getting status:", err)
+			os.Exit(1)
+		}
+
+		inSync := len(strays) == 0
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SOURCE\tREF\tLOCKED\tSTORE\tLINK")
+		for _, st := range statuses {
+			locked := "-"
+			if st.IsLocked {
+				locked = shortHash(st.Locked.Commit)
+			} else if st.Locked.ReferenceName != "" {
+				locked = fmt.Sprintf("%s (%s)", shortHash(st.Locked.Commit), st.Locked.ReferenceName)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", st.Source.ShortName(), st.Source.ReferenceName, locked, st.Store, st.Link)
+			inSync = inSync && st.InSync()
+		}
+		tw.Flush()
+
+		if len(strays) != 0 {
+			fmt.Println()
+			fmt.Println("stray entries in vendored:")
+			for _, stray := range strays {
+				fmt.Println("  " + stray)
+			}
+		}
+
+		if !inSync {
+			fmt.Println()
+			fmt.Println("out of sync, run vend sync")
+			os.Exit(1)
+		}
+	},
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/blobs"
+	"vend/internal/config"
+	"vend/internal/units"
+	"vend/internal/user"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	storeCmd = &cobra.Command{
+		Use:   "store",
+		Short: "Inspect and maintain the global vend directory",
+	}
+
+	storeStatsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Show how much space the deduplication of the global vend directory saves",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			pool := blobs.New(user.Location())
+			st, err := pool.Stats()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error reading blob pool:", err)
+				os.Exit(1)
+			}
+			entries, err := config.StoreEntries()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error reading store:", err)
+				os.Exit(1)
+			}
+			mirrors, err := config.StoreMirrors()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error reading store:", err)
+				os.Exit(1)
+			}
+			var objectsSaved int64
+			for _, mirror := range mirrors {
+				saved, err := blobs.SharedSize(filepath.Join(mirror, "objects"))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "error reading mirror:", err)
+					os.Exit(1)
+				}
+				objectsSaved += saved
+			}
+
+			fmt.Printf("location       %s\n", user.Location())
+			fmt.Printf("entries        %d\n", len(entries))
+			fmt.Printf("blobs          %d (%s)\n", st.Blobs, units.FormatBytes(uint64(st.Size)))
+			fmt.Printf("linked files   %d\n", st.Links)
+			fmt.Printf("saved (files)  %s\n", units.FormatBytes(uint64(st.Saved)))
+			fmt.Printf("saved (git)    %s\n", units.FormatBytes(uint64(objectsSaved)))
+			fmt.Printf("saved (total)  %s\n", units.FormatBytes(uint64(st.Saved+objectsSaved)))
+			if st.Unused != 0 {
+				fmt.Printf("unused blobs   %d (%s), remove them with vend store dedupe\n", st.Unused, units.FormatBytes(uint64(st.UnusedSize)))
+			}
+		},
+	}
+
+	storeDedupeCmd = &cobra.Command{
+		Use:   "dedupe",
+		Short: "Move the files of all store entries into the blob pool and remove unused blobs",
+		Long: `Move the files of all store entries into the blob pool and remove unused blobs.
+New checkouts are deduplicated automatically, this converts entries created by older versions of vend.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			pool := blobs.New(user.Location())
+			entries, err := config.StoreEntries()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error reading store:", err)
+				os.Exit(1)
+			}
+			before, err := pool.Stats()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error reading blob pool:", err)
+				os.Exit(1)
+			}
+			for _, entry := range entries {
+				if err := pool.Deduplicate(entry); err != nil {
+					fmt.Fprintf(os.Stderr, "error deduplicating %s: %v\n", entry, err)
+					os.Exit(1)
+				}
+			}
+			count, size, err := pool.Prune()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error removing unused blobs:", err)
+				os.Exit(1)
+			}
+			after, err := pool.Stats()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error reading blob pool:", err)
+				os.Exit(1)
+			}
+			fmt.Printf("deduplicated %d entries, saving %s more\n", len(entries), units.FormatBytes(uint64(max(after.Saved-before.Saved, 0))))
+			if count != 0 {
+				fmt.Printf("removed %d unused blobs (%s)\n", count, units.FormatBytes(uint64(size)))
+			}
+		},
+	}
+)
+
+func init() {
+	storeCmd.AddCommand(storeStatsCmd)
+	storeCmd.AddCommand(storeDedupeCmd)
+	rootCmd.AddCommand(storeCmd)
+}
@@ -26,7 +26,10 @@ var syncCmd = &cobra.Command{
 			return
 		}
 
-		c.Sync()
+		if err := c.Sync(); err != nil {
+			fmt.Fprintln(os.Stderr, "error syncing sources:", err)
+			os.Exit(1)
+		}
 	},
 }
 
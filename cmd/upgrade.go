@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"vend/internal/config"
+	"vend/internal/picker"
+
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [source...]",
+	Short: "Pick a new reference for sources",
+	Long: `Pick a new reference for the given sources, or for all sources if none are given.
+The references are picked interactively from the tags and branches of the remote.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading config:", err)
+			os.Exit(1)
+		}
+
+		dir := filepath.Dir(c.Location)
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to change directory into %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+
+		if len(args) == 0 {
+			for _, s := range c.Sources {
+				args = append(args, s.Url)
+			}
+		}
+
+		changed := false
+		for _, arg := range args {
+			source, err := c.Find(arg)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			refs, err := config.ListRemote(source.CanonicalUrl())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			ref, err := pickReference(refs, source.Url, source.ReferenceName)
+			if errors.Is(err, picker.ErrCanceled) {
+				continue
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error picking reference:", err)
+				os.Exit(1)
+			}
+			if ref == source.ReferenceName {
+				continue
+			}
+			if err := c.SetReference(source.Url, ref); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s: %s -> %s\n", source.ShortName(), source.ReferenceName, ref)
+			changed = true
+		}
+
+		if !changed {
+			return
+		}
+		if err := c.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, "error saving config:", err)
+			os.Exit(1)
+		}
+		if err := c.Sync(); err != nil {
+			fmt.Fprintln(os.Stderr, "error syncing sources:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+}
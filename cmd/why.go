@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var whyCmd = &cobra.Command{
+	Use:   "why <source>",
+	Short: "Show every path through which a source or submodule is pulled in",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g := loadGraph()
+
+		ids := g.Match(args[0])
+		if len(ids) == 0 {
+			fmt.Fprintf(os.Stderr, "%s is not part of the dependency graph\n", args[0])
+			os.Exit(1)
+		}
+		for _, id := range ids {
+			for _, path := range g.Paths(id) {
+				names := make([]string, len(path))
+				for i, step := range path {
+					n, _ := g.Node(step)
+					names[i] = n.Name
+					if n.Ref != "" {
+						names[i] += "@" + n.Ref
+					}
+				}
+				fmt.Println(strings.Join(names, " -> "))
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whyCmd)
+}
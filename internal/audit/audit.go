@@ -0,0 +1,236 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"vend/internal/config"
+	"vend/internal/semver"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type Finding struct {
+	Source   config.Source
+	Advisory Advisory
+	Severity Severity
+	Fixed    []string
+	// Unverified is set if the advisory has commit ranges that couldn't be evaluated
+	// because the history of the locked commit is incomplete.
+	Unverified bool
+}
+
+// maxAncestors limits how much history is read to evaluate commit ranges.
+const maxAncestors = 100_000
+
+// Check returns all advisories affecting the given source at its locked commit.
+func (db *Database) Check(source config.Source, locked config.LockedSource) ([]Finding, error) {
+	repo := normalizeRepo(source.CanonicalUrl())
+	ref := strings.TrimPrefix(strings.TrimPrefix(source.ReferenceName, "refs/tags/"), "refs/heads/")
+
+	var (
+		findings   []Finding
+		ancestors  map[string]bool
+		incomplete bool
+	)
+	for _, advisory := range db.Advisories {
+		var (
+			unverified      *affected
+			unverifiedFixed []string
+		)
+		for _, aff := range advisory.Affected {
+			if !affectsRepo(aff, repo) {
+				continue
+			}
+			isAffected := slices.Contains(aff.Versions, ref) ||
+				(locked.Commit != "" && slices.Contains(aff.Versions, locked.Commit))
+			var fixed []string
+			evaluated := true
+			for _, r := range aff.Ranges {
+				for _, e := range r.Events {
+					if e.Fixed != "" {
+						fixed = append(fixed, e.Fixed)
+					}
+				}
+				if isAffected {
+					continue
+				}
+				switch r.Type {
+				case "GIT":
+					if locked.Commit == "" {
+						continue
+					}
+					if ancestors == nil && !incomplete {
+						var err error
+						ancestors, err = ancestorsOf(source, locked.Commit)
+						if errors.Is(err, config.ErrIncompleteHistory) {
+							incomplete = true
+						} else if err != nil {
+							return nil, err
+						}
+					}
+					if incomplete {
+						evaluated = false
+						continue
+					}
+					isAffected = commitInRange(r.Events, locked.Commit, ancestors)
+				case "SEMVER", "ECOSYSTEM":
+					isAffected = versionInRange(r.Events, ref)
+				}
+			}
+			if isAffected {
+				findings = append(findings, Finding{
+					Source:   source,
+					Advisory: advisory,
+					Severity: advisory.severity(aff),
+					Fixed:    fixed,
+				})
+				unverified = nil
+				break
+			}
+			if !evaluated && unverified == nil {
+				unverified, unverifiedFixed = &aff, fixed
+			}
+		}
+		if unverified != nil {
+			findings = append(findings, Finding{
+				Source:     source,
+				Advisory:   advisory,
+				Severity:   advisory.severity(*unverified),
+				Fixed:      unverifiedFixed,
+				Unverified: true,
+			})
+		}
+	}
+	return findings, nil
+}
+
+func affectsRepo(aff affected, repo string) bool {
+	for _, r := range aff.Ranges {
+		if r.Repo != "" && normalizeRepo(r.Repo) == repo {
+			return true
+		}
+	}
+	if aff.Package.Name != "" && normalizeRepo(aff.Package.Name) == repo {
+		return true
+	}
+	if after, ok := strings.CutPrefix(aff.Package.Purl, "pkg:github/"); ok {
+		name, _, _ := strings.Cut(after, "@")
+		return "github.com/"+strings.ToLower(name) == repo
+	}
+	return false
+}
+
+// normalizeRepo reduces a repository URL to "host/path" for comparison.
+func normalizeRepo(u string) string {
+	u = strings.TrimPrefix(strings.TrimSpace(u), "git+")
+	if parsed, err := url.Parse(u); err == nil && parsed.Host != "" {
+		u = parsed.Host + parsed.Path
+	} else if _, after, ok := strings.Cut(u, "@"); ok {
+		// scp-like syntax: git@host:path
+		u = strings.Replace(after, ":", "/", 1)
+	}
+	u = strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+	return strings.ToLower(u)
+}
+
+// ancestorsOf collects the commit and its ancestors from the mirror or the store entry.
+// It fails with config.ErrIncompleteHistory if neither has the complete history, e.g. for shallow clones.
+func ancestorsOf(source config.Source, commit string) (map[string]bool, error) {
+	repo, err := source.History(plumbing.NewHash(commit))
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.Log(&git.LogOptions{From: plumbing.NewHash(commit)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", source.Url, err)
+	}
+	defer iter.Close()
+	ancestors := make(map[string]bool)
+	err = iter.ForEach(func(c *object.Commit) error {
+		ancestors[c.Hash.String()] = true
+		if len(ancestors) >= maxAncestors {
+			return errStop
+		}
+		return nil
+	})
+	if errors.Is(err, errStop) {
+		return nil, fmt.Errorf("%w of %s: more than %d commits", config.ErrIncompleteHistory, source.Url, maxAncestors)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", source.Url, err)
+	}
+	return ancestors, nil
+}
+
+var errStop = errors.New("stop")
+
+func hasAncestor(ancestors map[string]bool, commit string) bool {
+	if ancestors[commit] {
+		return true
+	}
+	// advisories may use abbreviated hashes
+	if len(commit) >= 7 && len(commit) < 40 {
+		for a := range ancestors {
+			if strings.HasPrefix(a, commit) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func commitInRange(events []event, commit string, ancestors map[string]bool) bool {
+	introduced := false
+	for _, e := range events {
+		switch {
+		case e.Introduced == "0":
+			introduced = true
+		case e.Introduced != "" && hasAncestor(ancestors, e.Introduced):
+			introduced = true
+		}
+	}
+	if !introduced {
+		return false
+	}
+	for _, e := range events {
+		if e.Fixed != "" && hasAncestor(ancestors, e.Fixed) {
+			return false
+		}
+		if e.LastAffected != "" && !strings.HasPrefix(commit, e.LastAffected) && hasAncestor(ancestors, e.LastAffected) {
+			return false
+		}
+	}
+	return true
+}
+
+func versionInRange(events []event, ref string) bool {
+	v, ok := semver.Parse(ref)
+	if !ok {
+		return false
+	}
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" {
+				affected = true
+			} else if iv, ok := semver.Parse(e.Introduced); ok && semver.Compare(v, iv) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if fv, ok := semver.Parse(e.Fixed); ok && semver.Compare(v, fv) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if lv, ok := semver.Parse(e.LastAffected); ok && semver.Compare(v, lv) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
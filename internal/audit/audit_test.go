@@ -0,0 +1,83 @@
+package audit
+
+import "testing"
+
+func TestCommitInRange(t *testing.T) {
+	const (
+		first  = "1111111111111111111111111111111111111111"
+		second = "2222222222222222222222222222222222222222"
+		third  = "3333333333333333333333333333333333333333"
+		other  = "4444444444444444444444444444444444444444"
+	)
+	// history of the locked commit third
+	ancestors := map[string]bool{first: true, second: true, third: true}
+	tests := []struct {
+		name   string
+		events []event
+		want   bool
+	}{
+		{"introduced at the beginning", []event{{Introduced: "0"}}, true},
+		{"introduced before", []event{{Introduced: second}}, true},
+		{"introduced with abbreviated hash", []event{{Introduced: "2222222"}}, true},
+		{"introduced on another branch", []event{{Introduced: other}}, false},
+		{"fixed before", []event{{Introduced: first}, {Fixed: second}}, false},
+		{"fixed with abbreviated hash", []event{{Introduced: "0"}, {Fixed: "3333333"}}, false},
+		{"fixed on another branch", []event{{Introduced: "0"}, {Fixed: other}}, true},
+		{"last affected is the commit", []event{{Introduced: "0"}, {LastAffected: third}}, true},
+		{"last affected before", []event{{Introduced: "0"}, {LastAffected: second}}, false},
+		{"abbreviations shorter than 7 characters", []event{{Introduced: "22"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitInRange(tt.events, third, ancestors); got != tt.want {
+				t.Errorf("commitInRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionInRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []event
+		ref    string
+		want   bool
+	}{
+		{"introduced at the beginning", []event{{Introduced: "0"}}, "v1.0.0", true},
+		{"before introduced", []event{{Introduced: "1.2.0"}}, "v1.1.0", false},
+		{"at introduced", []event{{Introduced: "1.2.0"}}, "v1.2.0", true},
+		{"before fixed", []event{{Introduced: "0"}, {Fixed: "1.2.0"}}, "v1.1.9", true},
+		{"at fixed", []event{{Introduced: "0"}, {Fixed: "1.2.0"}}, "v1.2.0", false},
+		{"at last affected", []event{{Introduced: "0"}, {LastAffected: "1.2.0"}}, "v1.2.0", true},
+		{"after last affected", []event{{Introduced: "0"}, {LastAffected: "1.2.0"}}, "v1.2.1", false},
+		{"reintroduced", []event{{Introduced: "1.0.0"}, {Fixed: "1.1.0"}, {Introduced: "2.0.0"}}, "v2.1.0", true},
+		{"between ranges", []event{{Introduced: "1.0.0"}, {Fixed: "1.1.0"}, {Introduced: "2.0.0"}}, "v1.5.0", false},
+		{"not a version", []event{{Introduced: "0"}}, "main", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionInRange(tt.events, tt.ref); got != tt.want {
+				t.Errorf("versionInRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRepo(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/Owner/Repo.git", "github.com/owner/repo"},
+		{"git+https://github.com/owner/repo/", "github.com/owner/repo"},
+		{"git@github.com:owner/repo.git", "github.com/owner/repo"},
+		{"github.com/owner/repo", "github.com/owner/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := normalizeRepo(tt.url); got != tt.want {
+				t.Errorf("normalizeRepo() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// Advisory is a vulnerability in the OSV format (https://ossf.github.io/osv-schema/).
+	Advisory struct {
+		ID               string           `json:"id"`
+		Summary          string           `json:"summary"`
+		Aliases          []string         `json:"aliases"`
+		Severity         []severityScore  `json:"severity"`
+		Affected         []affected       `json:"affected"`
+		DatabaseSpecific databaseSpecific `json:"database_specific"`
+	}
+
+	severityScore struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	}
+
+	databaseSpecific struct {
+		Severity string `json:"severity"`
+	}
+
+	affected struct {
+		Package           affectedPackage  `json:"package"`
+		Ranges            []affectedRange  `json:"ranges"`
+		Versions          []string         `json:"versions"`
+		EcosystemSpecific databaseSpecific `json:"ecosystem_specific"`
+		DatabaseSpecific  databaseSpecific `json:"database_specific"`
+	}
+
+	affectedPackage struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+		Purl      string `json:"purl"`
+	}
+
+	affectedRange struct {
+		Type   string  `json:"type"`
+		Repo   string  `json:"repo"`
+		Events []event `json:"events"`
+	}
+
+	event struct {
+		Introduced   string `json:"introduced,omitempty"`
+		Fixed        string `json:"fixed,omitempty"`
+		LastAffected string `json:"last_affected,omitempty"`
+		Limit        string `json:"limit,omitempty"`
+	}
+
+	Database struct {
+		Advisories []Advisory
+	}
+)
+
+// LoadDatabase reads OSV advisories from a JSON file or from all JSON files in a directory.
+// A file may contain a single advisory or an array of advisories.
+func LoadDatabase(path string) (*Database, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open advisory database: %w", err)
+	}
+	db := &Database{}
+	if !fi.IsDir() {
+		if err := db.loadFile(path); err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".json") {
+			return nil
+		}
+		return db.loadFile(p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *Database) loadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read advisory file: %w", err)
+	}
+	trimmed := strings.TrimSpace(string(b))
+	if strings.HasPrefix(trimmed, "[") {
+		var advisories []Advisory
+		if err := json.Unmarshal(b, &advisories); err != nil {
+			return fmt.Errorf("failed to decode advisory file %s: %w", path, err)
+		}
+		db.Advisories = append(db.Advisories, advisories...)
+		return nil
+	}
+	var advisory Advisory
+	if err := json.Unmarshal(b, &advisory); err != nil {
+		return fmt.Errorf("failed to decode advisory file %s: %w", path, err)
+	}
+	db.Advisories = append(db.Advisories, advisory)
+	return nil
+}
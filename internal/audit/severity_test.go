@@ -0,0 +1,53 @@
+package audit
+
+import "testing"
+
+func TestCvss3Score(t *testing.T) {
+	tests := []struct {
+		vector string
+		score  float64
+		ok     bool
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10, true},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, true},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8, true},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", 7.2, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:N/I:N/A:N", 0, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", 0, false},
+		{"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 0, false},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			score, ok := cvss3Score(tt.vector)
+			if score != tt.score || ok != tt.ok {
+				t.Errorf("cvss3Score() = %v, %v, want %v, %v", score, ok, tt.score, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	critical := []severityScore{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}}
+	tests := []struct {
+		name     string
+		advisory Advisory
+		aff      affected
+		want     Severity
+	}{
+		{"cvss", Advisory{Severity: critical}, affected{}, SeverityCritical},
+		{"database specific wins over cvss", Advisory{Severity: critical, DatabaseSpecific: databaseSpecific{Severity: "MODERATE"}}, affected{}, SeverityMedium},
+		{"affected wins over advisory", Advisory{DatabaseSpecific: databaseSpecific{Severity: "HIGH"}}, affected{EcosystemSpecific: databaseSpecific{Severity: "low"}}, SeverityLow},
+		{"unknown", Advisory{}, affected{}, SeverityUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.advisory.severity(tt.aff); got != tt.want {
+				t.Errorf("severity() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Package blobs deduplicates the files of store entries in a content-addressed pool.
+// Every file of an entry is a hard link to a read-only blob named after the hash of its content,
+// so identical files of different entries take space only once.
+//
+// Store entries must not be written after they were deduplicated, vend checks out a new entry instead.
+// The read-only mode only protects against accidental writes: writing a file anyway, e.g. as root or
+// after changing its mode, changes the blob and every entry that links to it. Such entries are reported
+// as modified by vend status, and a blob whose content no longer matches its name is never linked again.
+package blobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"vend/internal/filelock"
+)
+
+const (
+	// DirName is the name of the pool directory in the store.
+	DirName = ".blobs"
+	// executableSuffix marks blobs of executable files, a hard link can't have its own mode.
+	executableSuffix = ".x"
+)
+
+type Pool struct {
+	Dir string
+}
+
+// New returns the pool in the given store directory.
+func New(store string) Pool {
+	return Pool{Dir: filepath.Join(store, DirName)}
+}
+
+// lock is held shared while files are added to the pool and exclusively while unused blobs are deleted,
+// so a blob is never deleted between being found and being linked.
+func (p Pool) lock(exclusive bool) (func(), error) {
+	if exclusive {
+		return filelock.Lock(p.Dir + ".lock")
+	}
+	return filelock.RLock(p.Dir + ".lock")
+}
+
+// Deduplicate replaces every file in dir with a hard link into the pool and makes it read-only.
+// Git metadata and symlinks are left alone. Files that can't be hard linked are reflinked
+// or kept as read-only copies.
+func (p Pool) Deduplicate(dir string) error {
+	unlock, err := p.lock(false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return p.add(path, fi)
+	})
+}
+
+func (p Pool) add(path string, fi fs.FileInfo) error {
+	hash, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	mode := fs.FileMode(0444)
+	blob := filepath.Join(p.Dir, hash[:2], hash)
+	if fi.Mode()&0111 != 0 {
+		mode = 0555
+		blob += executableSuffix
+	}
+
+	existing, err := os.Stat(blob)
+	if err == nil && os.SameFile(existing, fi) {
+		return nil
+	}
+	if err == nil {
+		if err = p.checkBlob(blob, hash); err != nil {
+			return err
+		}
+		_, err = os.Stat(blob)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return err
+		}
+		if links, ok := linkCount(fi); ok && links > 1 {
+			// the file was changed in place and still shares its inode with another blob
+			if err := unlink(path); err != nil {
+				return err
+			}
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+		err = os.Link(path, blob)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			// the file stays a read-only copy
+			return nil
+		}
+		// another entry added the same blob concurrently
+	} else if err != nil {
+		return err
+	}
+	return replace(blob, path, mode)
+}
+
+// checkBlob takes the blob out of the pool if its content was written after it was added.
+// The entries linking to it keep the changed content.
+func (p Pool) checkBlob(blob, hash string) error {
+	actual, err := hashFile(blob)
+	if err != nil {
+		return err
+	}
+	if actual == hash {
+		return nil
+	}
+	if err := os.Remove(blob); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove modified blob %s: %w", blob, err)
+	}
+	return nil
+}
+
+// replace atomically swaps the file at path for a link to the blob.
+func replace(blob, path string, mode fs.FileMode) error {
+	tmp := filepath.Join(filepath.Dir(path), ".blob-"+filepath.Base(path))
+	_ = os.Remove(tmp)
+	if err := os.Link(blob, tmp); err != nil {
+		if err := cloneFile(blob, tmp); err != nil {
+			return os.Chmod(path, mode)
+		}
+		if err := os.Chmod(tmp, mode); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// unlink replaces the file at path with a copy that doesn't share its inode.
+func unlink(path string) error {
+	tmp := filepath.Join(filepath.Dir(path), ".blob-"+filepath.Base(path))
+	_ = os.Remove(tmp)
+	if err := cloneFile(path, tmp); err != nil {
+		if err := copyFile(path, tmp); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,151 @@
+//go:build !windows
+
+package blobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		mode := os.FileMode(0644)
+		if filepath.Ext(name) == ".sh" {
+			mode = 0755
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+	fa, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(fa, fb)
+}
+
+func TestDeduplicate(t *testing.T) {
+	store := t.TempDir()
+	pool := New(store)
+	a, b := filepath.Join(store, "a"), filepath.Join(store, "b")
+	writeFiles(t, a, map[string]string{"README": "shared", "run.sh": "shared", "a.txt": "a", ".git/config": "shared"})
+	writeFiles(t, b, map[string]string{"docs/README": "shared", "b.txt": "b"})
+
+	for _, dir := range []string{a, b} {
+		if err := pool.Deduplicate(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"identical files", "a/README", "b/docs/README", true},
+		{"executable and regular file", "a/run.sh", "a/README", false},
+		{"different content", "a/a.txt", "b/b.txt", false},
+		{"git metadata", "a/.git/config", "a/README", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameFile(t, filepath.Join(store, tt.a), filepath.Join(store, tt.b)); got != tt.same {
+				t.Errorf("same file = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	modes := map[string]os.FileMode{"a/README": 0444, "a/run.sh": 0555, "a/.git/config": 0644}
+	for name, want := range modes {
+		fi, err := os.Stat(filepath.Join(store, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode().Perm(); got != want {
+			t.Errorf("mode of %s = %o, want %o", name, got, want)
+		}
+	}
+}
+
+func TestDeduplicateModifiedBlob(t *testing.T) {
+	store := t.TempDir()
+	pool := New(store)
+	a, b := filepath.Join(store, "a"), filepath.Join(store, "b")
+	writeFiles(t, a, map[string]string{"file": "original"})
+	if err := pool.Deduplicate(a); err != nil {
+		t.Fatal(err)
+	}
+
+	// a write that ignores the read-only mode changes the blob
+	path := filepath.Join(a, "file")
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, b, map[string]string{"file": "original"})
+	if err := pool.Deduplicate(b); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(b, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "original" {
+		t.Errorf("content = %q, want the original content", content)
+	}
+	if sameFile(t, path, filepath.Join(b, "file")) {
+		t.Error("new entry links to the modified blob")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store := t.TempDir()
+	pool := New(store)
+	a, b := filepath.Join(store, "a"), filepath.Join(store, "b")
+	writeFiles(t, a, map[string]string{"shared": "shared", "only-a": "a"})
+	writeFiles(t, b, map[string]string{"shared": "shared"})
+	for _, dir := range []string{a, b} {
+		if err := pool.Deduplicate(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.RemoveAll(a); err != nil {
+		t.Fatal(err)
+	}
+	count, size, err := pool.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || size != 1 {
+		t.Errorf("Prune() = %d, %d, want 1, 1", count, size)
+	}
+
+	st, err := pool.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Blobs != 1 || st.Unused != 0 || st.Links != 1 {
+		t.Errorf("Stats() = %+v, want one used blob", st)
+	}
+	if _, err := os.Stat(filepath.Join(b, "shared")); err != nil {
+		t.Errorf("linked file was removed: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package blobs
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+func linkCount(fi fs.FileInfo) (uint64, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Nlink), true
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package blobs
+
+import "io/fs"
+
+// linkCount is not available from the file info on Windows.
+func linkCount(fi fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
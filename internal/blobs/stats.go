@@ -0,0 +1,116 @@
+package blobs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Stats describes how much space the pool saves.
+type Stats struct {
+	Blobs int
+	// Size is the space taken by all blobs.
+	Size int64
+	// Links is the number of files in store entries that are links to a blob.
+	Links int
+	// Saved is the space the links would take as separate files.
+	Saved int64
+	// Unused blobs are not linked from any store entry anymore.
+	Unused     int
+	UnusedSize int64
+}
+
+// Stats walks the pool. Link counts are only available on platforms that report them.
+func (p Pool) Stats() (Stats, error) {
+	var st Stats
+	err := filepath.WalkDir(p.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == p.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		st.Blobs++
+		st.Size += fi.Size()
+		links, ok := linkCount(fi)
+		if !ok {
+			return nil
+		}
+		if links <= 1 {
+			st.Unused++
+			st.UnusedSize += fi.Size()
+			return nil
+		}
+		st.Links += int(links - 1)
+		st.Saved += fi.Size() * int64(links-2)
+		return nil
+	})
+	return st, err
+}
+
+// Prune deletes the blobs that no store entry links to anymore.
+// It does nothing on platforms without link counts.
+func (p Pool) Prune() (int, int64, error) {
+	unlock, err := p.lock(true)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+	var count int
+	var size int64
+	err = filepath.WalkDir(p.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == p.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if links, ok := linkCount(fi); ok && links <= 1 {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			count++
+			size += fi.Size()
+		}
+		return nil
+	})
+	return count, size, err
+}
+
+// SharedSize returns the space saved by files under dir that are hard linked more than once,
+// e.g. the git objects shared between a mirror and the store entries checked out from it.
+func SharedSize(dir string) (int64, error) {
+	var saved int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if links, ok := linkCount(fi); ok && links > 1 {
+			saved += fi.Size() * int64(links-1)
+		}
+		return nil
+	})
+	return saved, err
+}
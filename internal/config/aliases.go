@@ -0,0 +1,74 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+	"vend/internal/settings"
+)
+
+// builtinAliases are the host aliases that are always available.
+var builtinAliases = map[string]string{
+	"gh": "https://github.com/",
+	"gl": "https://gitlab.com/",
+	"bb": "https://bitbucket.org/",
+}
+
+var shorthandRE = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_-]*):([^/].*)$`)
+
+// ExpandUrl expands shorthands like "gh:owner/repo" into the canonical URL.
+// Aliases of the project take precedence over the global aliases from the settings,
+// which take precedence over the builtin aliases.
+// URLs that are no shorthands are returned unchanged.
+func (c *Config) ExpandUrl(u string) string {
+	if c != nil {
+		if expanded, ok := expandAlias(u, c.Aliases); ok {
+			return expanded
+		}
+	}
+	return expandUrl(u)
+}
+
+func expandUrl(u string) string {
+	if s, err := settings.Get(); err == nil {
+		if expanded, ok := expandAlias(u, s.Aliases); ok {
+			return expanded
+		}
+	}
+	if expanded, ok := expandAlias(u, builtinAliases); ok {
+		return expanded
+	}
+	return u
+}
+
+// expandAlias expands the shorthand using the given aliases.
+// An alias is either a URL prefix or a template containing "{path}".
+func expandAlias(u string, aliases map[string]string) (string, bool) {
+	match := shorthandRE.FindStringSubmatch(u)
+	if match == nil {
+		return u, false
+	}
+	target, ok := aliases[match[1]]
+	if !ok {
+		return u, false
+	}
+	path := strings.TrimSuffix(strings.Trim(match[2], "/"), ".git")
+	if strings.Contains(target, "{path}") {
+		return strings.ReplaceAll(target, "{path}", path), true
+	}
+	return strings.TrimSuffix(target, "/") + "/" + path + ".git", true
+}
+
+// CanonicalUrl is the URL of the source with all shorthands expanded.
+func (s Source) CanonicalUrl() string {
+	if s.canonicalUrl != "" {
+		return s.canonicalUrl
+	}
+	return expandUrl(s.Url)
+}
+
+// resolveUrls expands the shorthands of all sources using the aliases of the project.
+func (c *Config) resolveUrls() {
+	for i := range c.Sources {
+		c.Sources[i].canonicalUrl = c.ExpandUrl(c.Sources[i].Url)
+	}
+}
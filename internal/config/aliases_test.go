@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestExpandAlias(t *testing.T) {
+	aliases := map[string]string{
+		"gh":     "https://github.com/",
+		"corp":   "https://git.corp",
+		"mirror": "https://mirror.corp/git/{path}.git",
+		"ssh":    "git@git.corp:{path}",
+	}
+	tests := []struct {
+		name   string
+		url    string
+		want   string
+		wantOk bool
+	}{
+		{"prefix", "gh:owner/repo", "https://github.com/owner/repo.git", true},
+		{"prefix without slash", "corp:team/lib", "https://git.corp/team/lib.git", true},
+		{"git suffix", "gh:owner/repo.git", "https://github.com/owner/repo.git", true},
+		{"trailing slash", "gh:owner/repo/", "https://github.com/owner/repo.git", true},
+		{"template", "mirror:team/lib", "https://mirror.corp/git/team/lib.git", true},
+		{"template without scheme", "ssh:team/lib.git", "git@git.corp:team/lib", true},
+		{"unknown alias", "gl:owner/repo", "gl:owner/repo", false},
+		{"url", "https://github.com/owner/repo.git", "https://github.com/owner/repo.git", false},
+		{"scp-like url", "git@github.com:owner/repo.git", "git@github.com:owner/repo.git", false},
+		{"local path", "../repo", "../repo", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := expandAlias(tt.url, aliases)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("expandAlias(%q) = %q, %t, want %q, %t", tt.url, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestExpandUrlProjectAlias(t *testing.T) {
+	c := &Config{Aliases: map[string]string{"gh": "https://github.example.com/"}}
+	if got, want := c.ExpandUrl("gh:owner/repo"), "https://github.example.com/owner/repo.git"; got != want {
+		t.Errorf("ExpandUrl() = %q, want %q, project aliases take precedence", got, want)
+	}
+}
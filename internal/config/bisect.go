@@ -0,0 +1,257 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"vend/internal/sudo"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type BisectResult int
+
+const (
+	BisectGood BisectResult = iota
+	BisectBad
+	BisectSkip
+)
+
+// bisectSkipCode is the exit code of a script that can't test a commit, as in git bisect run.
+const bisectSkipCode = 125
+
+func (r BisectResult) String() string {
+	switch r {
+	case BisectGood:
+		return "good"
+	case BisectBad:
+		return "bad"
+	default:
+		return "skip"
+	}
+}
+
+// BisectStep is the outcome of testing one commit.
+type BisectStep struct {
+	Commit    *object.Commit
+	Remaining int
+	Result    BisectResult
+}
+
+// Bisect searches the first commit between good and bad of the source for which the script fails.
+// Every tested commit is checked out into the store and linked in place of the source,
+// the original link is restored and the store entries of the candidates are deleted when the search ends,
+// also if it is interrupted.
+// It follows the first parents of bad back to the last commit containing good, so a good commit
+// on a merged branch narrows the search down to the merge.
+func (c *Config) Bisect(source Source, good, bad, script string, report func(BisectStep)) (*object.Commit, error) {
+	if _, ok := c.Scripts[script]; !ok {
+		return nil, fmt.Errorf("script %s not found", script)
+	}
+
+	repo, err := source.Mirror(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	goodCommit, err := ResolveCommit(repo, good)
+	if err != nil {
+		return nil, err
+	}
+	badCommit, err := ResolveCommit(repo, bad)
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := goodCommit.IsAncestor(badCommit); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("%s is not an ancestor of %s", good, bad)
+	}
+
+	candidates, err := firstParents(goodCommit, badCommit)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%s and %s are the same commit", good, bad)
+	}
+
+	link := source.LinkPath()
+	if !filepath.IsAbs(link) {
+		wd, _ := os.Getwd()
+		link = filepath.Join(wd, link)
+	}
+	original, err := os.Readlink(link)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read link %s: %w", link, err)
+	}
+	var (
+		mu         sync.Mutex
+		downloaded []Source
+		finished   bool
+	)
+	cleanup := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if finished {
+			return
+		}
+		finished = true
+		_ = os.Remove(link)
+		if original != "" {
+			if err := sudo.Link([]sudo.LinkData{{Old: original, New: link}}); err != nil {
+				fmt.Fprintln(os.Stderr, "failed to restore link:", err)
+			}
+		}
+		for _, candidate := range downloaded {
+			if referenced, err := c.IsReferenced(candidate.DestPath()); err == nil && !referenced {
+				_ = candidate.Purge()
+			}
+		}
+	}
+	defer cleanup()
+	relink := func(dest string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if finished {
+			return errors.New("bisect interrupted")
+		}
+		if err := os.Remove(link); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove link %s: %w", link, err)
+		}
+		return sudo.Link([]sudo.LinkData{{Old: dest, New: link}})
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	defer func() {
+		signal.Stop(signals)
+		close(done)
+	}()
+	go func() {
+		select {
+		case <-signals:
+			cleanup()
+			fmt.Fprintln(os.Stderr, "bisect interrupted, link restored")
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	// candidates[hi] is known to be bad, everything before lo is known to be good.
+	lo, hi := 0, len(candidates)-1
+	skipped := make(map[int]bool)
+	for lo < hi {
+		mid := pickCandidate(lo, hi, skipped)
+		if mid < 0 {
+			hashes := make([]string, 0, hi-lo+1)
+			for _, commit := range candidates[lo : hi+1] {
+				hashes = append(hashes, commit.Hash.String())
+			}
+			return nil, fmt.Errorf("the first bad commit could be any of %s", strings.Join(hashes, ", "))
+		}
+		candidate := bisectCandidate(source, candidates[mid])
+		if _, err := os.Stat(candidate.DestPath()); errors.Is(err, os.ErrNotExist) {
+			mu.Lock()
+			downloaded = append(downloaded, candidate)
+			mu.Unlock()
+		}
+		result, err := c.bisectTest(candidate, relink, script)
+		if err != nil {
+			return nil, err
+		}
+		switch result {
+		case BisectGood:
+			lo = mid + 1
+		case BisectBad:
+			hi = mid
+		case BisectSkip:
+			skipped[mid] = true
+		}
+		if report != nil {
+			report(BisectStep{Commit: candidates[mid], Remaining: hi - lo, Result: result})
+		}
+	}
+	return candidates[hi], nil
+}
+
+// firstParents lists the first parents of bad that contain good, without good itself, oldest first.
+func firstParents(good, bad *object.Commit) ([]*object.Commit, error) {
+	var commits []*object.Commit
+	for commit := bad; commit.Hash != good.Hash; {
+		if ok, err := good.IsAncestor(commit); err != nil {
+			return nil, err
+		} else if !ok {
+			// good was merged in by the previous commit
+			break
+		}
+		commits = append(commits, commit)
+		if commit.NumParents() == 0 {
+			break
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		commit = parent
+	}
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
+
+// pickCandidate returns the untested commit closest to the middle of [lo, hi) or -1.
+func pickCandidate(lo, hi int, skipped map[int]bool) int {
+	mid := lo + (hi-lo)/2
+	for offset := 0; mid-offset >= lo || mid+offset < hi; offset++ {
+		if i := mid - offset; i >= lo && !skipped[i] {
+			return i
+		}
+		if i := mid + offset; i < hi && !skipped[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// bisectCandidate returns the source pinned to the commit.
+func bisectCandidate(source Source, commit *object.Commit) Source {
+	candidate := source
+	candidate.ReferenceName = commit.Hash.String()
+	candidate.canonicalRef = commit.Hash.String()
+	return candidate
+}
+
+// bisectTest links the candidate in place of the source with relink and runs the script.
+func (c *Config) bisectTest(candidate Source, relink func(dest string) error, script string) (BisectResult, error) {
+	if _, err := os.Stat(candidate.DestPath()); errors.Is(err, os.ErrNotExist) {
+		hash := plumbing.NewHash(candidate.canonicalRef)
+		ref := ResolvedRef{Kind: RefCommit, Name: plumbing.ReferenceName(hash.String()), Hash: hash}
+		if err := candidate.download(c.fetcher(), ref, nil); err != nil {
+			return BisectSkip, err
+		}
+	}
+	if err := relink(candidate.DestPath()); err != nil {
+		return BisectSkip, err
+	}
+
+	err := c.Run(script, nil)
+	if err == nil {
+		return BisectGood, nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return BisectSkip, err
+	}
+	if exitErr.ExitCode() == bisectSkipCode {
+		return BisectSkip, nil
+	}
+	return BisectBad, nil
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type (
+	ChangeKind int
+
+	// SourceChange is the difference of one source between two revisions of the project.
+	SourceChange struct {
+		Kind      ChangeKind
+		Source    Source
+		OldRef    string
+		NewRef    string
+		OldCommit string
+		NewCommit string
+	}
+)
+
+const (
+	SourceAdded ChangeKind = iota
+	SourceRemoved
+	SourceUpgraded
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case SourceAdded:
+		return "added"
+	case SourceRemoved:
+		return "removed"
+	case SourceUpgraded:
+		return "upgraded"
+	default:
+		return "unknown"
+	}
+}
+
+// Changes compares the sources of the project between two revisions of its git repository.
+func (c *Config) Changes(from, to string) ([]SourceChange, error) {
+	repo, err := git.PlainOpenWithOptions(filepath.Dir(c.Location), &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open project repository: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), filepath.Dir(c.Location))
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.ToSlash(rel)
+
+	oldConfig, oldLock, err := configAt(repo, from, dir)
+	if err != nil {
+		return nil, err
+	}
+	newConfig, newLock, err := configAt(repo, to, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []SourceChange
+	for _, s := range newConfig.Sources {
+		newLocked, _ := newLock.Find(s)
+		old, ok := oldConfig.find(s)
+		if !ok {
+			changes = append(changes, SourceChange{
+				Kind:      SourceAdded,
+				Source:    s,
+				NewRef:    s.ReferenceName,
+				NewCommit: newLocked.Commit,
+			})
+			continue
+		}
+		oldLocked, _ := oldLock.Find(old)
+		if shortRef(old.ReferenceName) != shortRef(s.ReferenceName) || oldLocked.Commit != newLocked.Commit {
+			changes = append(changes, SourceChange{
+				Kind:      SourceUpgraded,
+				Source:    s,
+				OldRef:    old.ReferenceName,
+				NewRef:    s.ReferenceName,
+				OldCommit: oldLocked.Commit,
+				NewCommit: newLocked.Commit,
+			})
+		}
+	}
+	for _, s := range oldConfig.Sources {
+		if _, ok := newConfig.find(s); !ok {
+			oldLocked, _ := oldLock.Find(s)
+			changes = append(changes, SourceChange{
+				Kind:      SourceRemoved,
+				Source:    s,
+				OldRef:    s.ReferenceName,
+				OldCommit: oldLocked.Commit,
+			})
+		}
+	}
+	return changes, nil
+}
+
+// find returns the source with the same canonical URL.
+func (c *Config) find(source Source) (Source, bool) {
+	for _, s := range c.Sources {
+		if s.CanonicalUrl() == source.CanonicalUrl() {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+// configAt reads config and lock in dir at the given revision.
+// Missing files result in an empty config or lock.
+func configAt(repo *git.Repository, rev string, dir string) (*Config, *Lock, error) {
+	commit, err := ResolveCommit(repo, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &Config{Sources: []Source{}}
+	l := &Lock{Version: 1, Sources: []LockedSource{}}
+
+	if f, err := commit.File(path.Join(dir, configFileName)); err == nil {
+		r, err := f.Reader()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer r.Close()
+		if c, err = Decode(r); err != nil {
+			return nil, nil, fmt.Errorf("%s at %s: %w", configFileName, rev, err)
+		}
+	} else if !errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil, err
+	}
+
+	if f, err := commit.File(path.Join(dir, lockFileName)); err == nil {
+		r, err := f.Reader()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer r.Close()
+		if l, err = DecodeLock(r); err != nil {
+			return nil, nil, fmt.Errorf("%s at %s: %w", lockFileName, rev, err)
+		}
+	} else if !errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil, err
+	}
+	return c, l, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
@@ -10,24 +11,47 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"vend/internal/semver"
 	"vend/internal/sudo"
 	"vend/internal/user"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/goccy/go-yaml"
 )
 
 type (
 	Config struct {
-		Version  uint              `yaml:"version"`
-		Scripts  map[string]string `yaml:"scripts"`
-		Location string            `yaml:"-"`
-		Sources  []Source          `yaml:"sources"`
+		Version        uint              `yaml:"version"`
+		Scripts        map[string]string `yaml:"scripts"`
+		Location       string            `yaml:"-"`
+		AllowedSigners string            `yaml:"allowed_signers,omitempty"`
+		Keyring        string            `yaml:"keyring,omitempty"`
+		LicensePolicy  *LicensePolicy    `yaml:"license_policy,omitempty"`
+		Aliases        map[string]string `yaml:"aliases,omitempty"`
+		Transitive     bool              `yaml:"transitive,omitempty"`
+		Conflicts      string            `yaml:"conflicts,omitempty"`
+		GitBackend     string            `yaml:"git_backend,omitempty"`
+		Sources        []Source          `yaml:"sources"`
 	}
 
 	Source struct {
-		Url           string `yaml:"url"`
-		ReferenceName string `yaml:"reference_name"`
+		Url             string `yaml:"url"`
+		ReferenceName   string `yaml:"reference_name"`
+		VerifySignature bool   `yaml:"verify_signature,omitempty"`
+		LinkName        string `yaml:"name,omitempty"`
+		Dest            string `yaml:"dest,omitempty"`
+		// SubmodulePolicy defaults to downloading all submodules recursively.
+		SubmodulePolicy *SubmodulePolicy `yaml:"submodules,omitempty"`
+		// Depth is the number of commits to download, 0 for the full history. It defaults to 1.
+		Depth *int        `yaml:"depth,omitempty"`
+		LFS   *LFSOptions `yaml:"lfs,omitempty"`
+		// Sparse limits the checkout to these directories.
+		Sparse []string `yaml:"sparse,omitempty"`
+
+		canonicalUrl string
+		// canonicalRef is the reference resolved from ReferenceName, see canonical.
+		canonicalRef string
 	}
 )
 
@@ -49,73 +73,192 @@ func New() Config {
 }
 
 func Load() (c *Config, err error) {
-	c = &Config{Sources: []Source{}}
 	wd, err := os.Getwd()
 	if err != nil {
-		return c, fmt.Errorf("failed to get working directory: %w", err)
+		return &Config{Sources: []Source{}}, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
 	// look for config file in working directory, if not found, look one directory up until found or at root directory
 	for {
 		configPath := filepath.Join(wd, configFileName)
 		if _, err := os.Stat(configPath); err == nil {
-			f, err := os.Open(configPath)
-			if err != nil {
-				return c, fmt.Errorf("failed to open config file: %w", err)
-			}
-			defer f.Close()
-			if err := yaml.NewDecoder(f).Decode(c); err != nil {
-				return c, fmt.Errorf("failed to decode config file: %w", err)
-			}
-			c.Location = configPath
-			break
+			return LoadFrom(configPath)
 		}
 		up := filepath.Dir(wd)
 		if up == wd {
-			return c, fmt.Errorf("config file not found")
+			return &Config{Sources: []Source{}}, fmt.Errorf("config file not found")
 		}
 		wd = up
 	}
+}
 
+// LoadFrom reads the config file at the given path.
+func LoadFrom(configPath string) (*Config, error) {
+	f, err := os.Open(configPath)
+	if err != nil {
+		return &Config{Sources: []Source{}}, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+	c, err := Decode(f)
+	c.Location = configPath
+	if err != nil {
+		return c, err
+	}
+	if lock, err := c.LoadLock(); err == nil {
+		for i, s := range c.Sources {
+			if locked, ok := lock.Find(s); ok && locked.ReferenceName == s.ReferenceName {
+				c.Sources[i].canonicalRef = locked.CanonicalRef
+			}
+		}
+	}
 	return c, nil
 }
 
+// Decode reads a config from r. The location of the returned config is not set.
+func Decode(r io.Reader) (*Config, error) {
+	c := &Config{Sources: []Source{}}
+	if err := yaml.NewDecoder(r).Decode(c); err != nil {
+		return c, fmt.Errorf("failed to decode config file: %w", err)
+	}
+	c.resolveUrls()
+	if !validBackend(c.GitBackend) {
+		return c, fmt.Errorf("unknown git backend %q, expected %s or %s", c.GitBackend, BackendGoGit, BackendGit)
+	}
+	for _, s := range c.Sources {
+		if s.Depth != nil && *s.Depth < 0 {
+			return c, fmt.Errorf("invalid depth %d for source %s", *s.Depth, s.Url)
+		}
+		if err := s.validateDest(); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// validateDest makes sure the link of the source stays inside the project directory.
+func (s Source) validateDest() error {
+	if s.Dest != "" && !filepath.IsLocal(filepath.FromSlash(s.Dest)) {
+		return fmt.Errorf("invalid dest %s for source %s, it must be a relative path inside the project", s.Dest, s.Url)
+	}
+	return nil
+}
+
 var sourceRE = regexp.MustCompile(`^(.+)@([^@]+)$`)
 
+// ParseSource parses a source in the '<url>@<ref>' format.
+// The reference name is optional, the URL may be a shorthand.
+func (c *Config) ParseSource(source string) Source {
+	s := Source{Url: source}
+	if match := sourceRE.FindStringSubmatch(source); len(match) == 3 {
+		s = Source{
+			Url:           match[1],
+			ReferenceName: match[2],
+		}
+	}
+	s.canonicalUrl = c.ExpandUrl(s.Url)
+	return s
+}
+
 func (c *Config) Add(source string) error {
 	match := sourceRE.FindStringSubmatch(source)
 	if len(match) != 3 {
 		return fmt.Errorf("invalid source format, expected '<url>@<tag>'")
 	}
-	for _, s := range c.Sources {
-		if s.Url == match[1] {
-			return fmt.Errorf("source %s exists already", source)
-		}
-	}
-	c.Sources = append(c.Sources, Source{
+	return c.AddSource(Source{
 		Url:           match[1],
 		ReferenceName: match[2],
 	})
+}
+
+func (c *Config) AddSource(source Source) error {
+	source.canonicalUrl = c.ExpandUrl(source.Url)
+	for _, s := range c.Sources {
+		if s.CanonicalUrl() == source.CanonicalUrl() {
+			return fmt.Errorf("source %s exists already", source.Url)
+		}
+	}
+	c.Sources = append(c.Sources, source)
 	return nil
 }
 
-func (c *Config) Remove(source string) error {
-	match := sourceRE.FindStringSubmatch(source)
-	if len(match) != 3 {
+// SetReference changes the reference of the source matching the given URL, name or short name.
+func (c *Config) SetReference(source string, ref string) error {
+	i, err := c.index(source)
+	if err != nil {
+		return err
+	}
+	c.Sources[i].ReferenceName = ref
+	c.Sources[i].canonicalRef = ""
+	return nil
+}
+
+// Validate checks that the reference of the source exists on the remote and returns its kind.
+// If no reference is set, the latest semver tag is used. Commits that are not the tip of
+// a reference are looked up in the history fetched into the mirror.
+func (s *Source) Validate() (RefKind, error) {
+	if err := s.validateDest(); err != nil {
+		return RefUnknown, err
+	}
+	refs, err := ListRemote(s.CanonicalUrl())
+	if err != nil {
+		return RefUnknown, err
+	}
+	if s.ReferenceName == "" {
+		latest, ok := semver.Latest(refs.Tags())
+		if !ok {
+			return RefUnknown, fmt.Errorf("no version tag found in %s, please specify a reference", s.Url)
+		}
+		s.ReferenceName = latest
+	}
+	resolved, err := refs.Resolve(s.ReferenceName)
+	if err != nil || resolved.Kind != RefCommit || !resolved.Hash.IsZero() {
+		return resolved.Kind, err
+	}
+	repo, err := s.FetchRefs(nil, s.ReferenceName)
+	if err != nil {
+		return resolved.Kind, err
+	}
+	hash, err := mirrorCommit(repo, resolved)
+	if err != nil {
+		return resolved.Kind, fmt.Errorf("%w in %s", err, s.Url)
+	}
+	s.canonicalRef = hash.String()
+	return resolved.Kind, nil
+}
+
+// Find returns the source matching the given URL, name or short name.
+// A source in the '<url>@<ref>' format is matched by its URL.
+func (c *Config) Find(source string) (Source, error) {
+	i, err := c.index(source)
+	if err != nil {
+		return Source{}, err
+	}
+	return c.Sources[i], nil
+}
+
+func (c *Config) index(source string) (int, error) {
+	if match := sourceRE.FindStringSubmatch(source); len(match) == 3 {
 		for i, s := range c.Sources {
-			if s.Url == source || s.Name() == source || s.ShortName() == source {
-				c.Sources = append(c.Sources[:i], c.Sources[i+1:]...)
-				return nil
+			if s.Url == match[1] || s.CanonicalUrl() == c.ExpandUrl(match[1]) {
+				return i, nil
 			}
 		}
 	}
 	for i, s := range c.Sources {
-		if s.Url == match[1] {
-			c.Sources = append(c.Sources[:i], c.Sources[i+1:]...)
-			return nil
+		if s.Url == source || s.CanonicalUrl() == c.ExpandUrl(source) || s.Name() == source || s.ShortName() == source {
+			return i, nil
 		}
 	}
-	return fmt.Errorf("source %s not found", source)
+	return -1, fmt.Errorf("source %s not found", source)
+}
+
+func (c *Config) Remove(source string) error {
+	i, err := c.index(source)
+	if err != nil {
+		return err
+	}
+	c.Sources = append(c.Sources[:i], c.Sources[i+1:]...)
+	return nil
 }
 
 func (c *Config) Run(script string, args []string) error {
@@ -161,10 +304,13 @@ func (c *Config) FromGitSubmodules() error {
 		if err != nil {
 			return fmt.Errorf("failed to get submodule head: %w", err)
 		}
-		refName := headRef.Name()
+		ref := headRef.Hash().String()
+		if headRef.Name().IsBranch() {
+			ref = headRef.Name().Short()
+		}
 		s := Source{
 			Url:           smUrl,
-			ReferenceName: refName.String(),
+			ReferenceName: ref,
 		}
 
 		cmd := exec.Command("git", "submodule", "deinit", "-f", sm.Config().Path)
@@ -193,13 +339,35 @@ func (c *Config) Save() error {
 	return nil
 }
 
-func (c *Config) Sync() {
+func (c *Config) Sync() error {
+	sources := make([]*Source, len(c.Sources))
+	for i := range c.Sources {
+		sources[i] = &c.Sources[i]
+	}
+	if err := resolveRefs(sources); err != nil {
+		return fmt.Errorf("failed to resolve references: %w", err)
+	}
+	// download the whole dependency graph first, a failed download leaves the links and the lock untouched
+	if err := c.CloneMultiple(c.Sources); err != nil {
+		return fmt.Errorf("failed to download sources: %w", err)
+	}
+	deps := make([]*dependency, 0, len(c.Sources))
+	if c.Transitive {
+		var err error
+		if deps, err = c.resolveDependencies(); err != nil {
+			return fmt.Errorf("failed to resolve transitive sources: %w", err)
+		}
+	} else {
+		for _, source := range c.Sources {
+			deps = append(deps, &dependency{source: source, direct: true})
+		}
+	}
+
 	vendoredDir := "vendored"
 	_ = os.MkdirAll(vendoredDir, 0755)
 	dirEntries, err := os.ReadDir(vendoredDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to read directory: %v", err)
-		return
+		return fmt.Errorf("failed to read directory: %w", err)
 	}
 	for _, entry := range dirEntries {
 		entryName := filepath.Join(vendoredDir, entry.Name())
@@ -223,33 +391,176 @@ func (c *Config) Sync() {
 		}
 	}
 
-	CloneMultiple(c.Sources)
-
+	previousLock, err := c.LoadLock()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ignoring previous lock:", err)
+	}
+	lock := &Lock{Version: 1, Sources: make([]LockedSource, 0, len(deps))}
+	var errs []error
 	wd, _ := os.Getwd()
-	linkData := make([]sudo.LinkData, 0, len(c.Sources))
-	for _, source := range c.Sources {
+	linkData := make([]sudo.LinkData, 0, len(deps))
+	for _, dep := range deps {
+		source := dep.source
+		locked, err := c.lockSource(source, previousLock)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %s not linked: %w", source.Url, err))
+			continue
+		}
+		locked.RequiredBy = dep.requiredBy
+		if !dep.direct {
+			locked.Transitive = true
+			locked.Name = source.LinkName
+			locked.Submodules = source.SubmodulePolicy
+			locked.Depth = source.Depth
+			locked.LFS = source.LFS
+			locked.Sparse = source.Sparse
+		}
+		lock.Sources = append(lock.Sources, locked)
+		link := source.LinkPath()
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(wd, link)
+		}
+		if source.Dest != "" {
+			if err := prepareLink(link); err != nil {
+				errs = append(errs, fmt.Errorf("source %s not linked: %w", source.Url, err))
+				continue
+			}
+		}
 		linkData = append(linkData, sudo.LinkData{
 			Old: source.DestPath(),
-			New: filepath.Join(wd, "vendored", source.ShortName()),
+			New: link,
 		})
 	}
-	sudo.Link(linkData)
+	if err := sudo.Link(linkData); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.SaveLock(lock); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.register(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		if err := c.record(lock); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lockSource resolves the commit of the cloned source and verifies its signature if requested.
+func (c *Config) lockSource(source Source, previousLock *Lock) (LockedSource, error) {
+	locked := LockedSource{
+		Url:           source.CanonicalUrl(),
+		ReferenceName: source.ReferenceName,
+	}
+	repo, err := git.PlainOpen(source.DestPath())
+	if err != nil {
+		return locked, fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return locked, fmt.Errorf("failed to get head: %w", err)
+	}
+	locked.Commit = head.Hash().String()
+	locked.CanonicalRef = source.canonical()
+	if source.canonicalRef == "" {
+		// the remote couldn't be reached and the source was never synced before
+		locked.CanonicalRef = canonicalRef(repo, source.ReferenceName, head)
+	}
+
+	if previous, ok := previousLock.Find(source); ok && previous.Commit == locked.Commit && previous.ContentHash != "" {
+		locked.ContentHash = previous.ContentHash
+	} else if locked.ContentHash, err = source.ContentHash(); err != nil {
+		return locked, err
+	}
+
+	if source.VerifySignature {
+		signer, err := c.verifySignature(source)
+		if err != nil {
+			return locked, fmt.Errorf("signature verification failed: %w", err)
+		}
+		locked.Signer = signer
+	}
+
+	if err := c.checkLicense(source); err != nil {
+		return locked, fmt.Errorf("license policy violated: %w", err)
+	}
+	return locked, nil
+}
+
+// canonicalRef determines the full name of the reference the store entry was cloned from.
+func canonicalRef(repo *git.Repository, ref string, head *plumbing.Reference) string {
+	if strings.HasPrefix(ref, "refs/") {
+		return ref
+	}
+	if _, err := repo.Reference(plumbing.NewTagReferenceName(ref), false); err == nil {
+		return plumbing.NewTagReferenceName(ref).String()
+	}
+	if head.Name().IsBranch() || !IsCommit(ref) {
+		return plumbing.NewBranchReferenceName(ref).String()
+	}
+	return head.Hash().String()
+}
+
+// prepareLink makes sure a link can be created at the given path outside the vendored directory.
+// An existing link is replaced, other files are never deleted.
+func prepareLink(link string) error {
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", link, err)
+	}
+	fi, err := os.Lstat(link)
+	if err != nil {
+		return nil
+	}
+	if fi.Mode().Type() != os.ModeSymlink {
+		return fmt.Errorf("%s exists and is not a link", link)
+	}
+	return os.Remove(link)
 }
 
 func (s Source) ShortName() string {
-	u, err := url.Parse(s.Url)
+	if s.LinkName != "" {
+		return s.LinkName
+	}
+	u, err := url.Parse(s.CanonicalUrl())
 	if err != nil {
-		return strings.TrimSuffix(unixpath.Base(s.Url), ".git")
+		return strings.TrimSuffix(unixpath.Base(s.CanonicalUrl()), ".git")
 	}
 	return strings.TrimSuffix(unixpath.Base(u.Path), ".git")
 }
 
+// Name is the path of the store entry relative to the store.
+// It is the same for short and full spellings of a reference, tags and branches of the same name get different entries.
 func (s Source) Name() string {
-	u, err := url.Parse(s.Url)
+	return s.entryName(refKey(s.canonical()))
+}
+
+func (s Source) entryName(ref string) string {
+	entry := ref
+	for _, key := range []string{s.depthKey(), s.SubmodulePolicy.key(), s.LFS.key(), s.sparseKey()} {
+		if key != "" {
+			entry += "+" + key
+		}
+	}
+	return filepath.Join(s.repoName(), entry)
+}
+
+// repoName is the part of the store path that only depends on the URL.
+func (s Source) repoName() string {
+	u, err := url.Parse(s.CanonicalUrl())
 	if err != nil {
-		return filepath.Join(strings.TrimSuffix(unixpath.Base(s.Url), ".git"), s.ReferenceName)
+		return strings.TrimSuffix(unixpath.Base(s.CanonicalUrl()), ".git")
+	}
+	return filepath.Join(u.Host, strings.TrimSuffix(u.Path, ".git"))
+}
+
+// LinkPath is the path of the link to the store entry, relative to the project directory.
+func (s Source) LinkPath() string {
+	if s.Dest != "" {
+		return filepath.FromSlash(s.Dest)
 	}
-	return filepath.Join(u.Host, strings.TrimSuffix(u.Path, ".git"), s.ReferenceName)
+	return filepath.Join("vendored", s.ShortName())
 }
 
 func (s Source) DestPath() string {
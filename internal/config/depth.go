@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"vend/internal/sudo"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// defaultDepth is the clone depth of sources without a depth.
+const defaultDepth = 1
+
+// CloneDepth returns the number of commits downloaded for the source, 0 meaning the full history.
+func (s Source) CloneDepth() int {
+	if s.Depth == nil {
+		return defaultDepth
+	}
+	return *s.Depth
+}
+
+// historyDepth is the depth of the history of the store entry, commits always have their full history.
+func (s Source) historyDepth() int {
+	if IsCommit(s.ReferenceName) {
+		return 0
+	}
+	return s.CloneDepth()
+}
+
+// depthKey distinguishes the store entries of the same reference with different history depths.
+// Commits are always cloned with their full history.
+func (s Source) depthKey() string {
+	depth := s.CloneDepth()
+	switch {
+	case depth == defaultDepth || IsCommit(s.ReferenceName):
+		return ""
+	case depth == 0:
+		return "full"
+	default:
+		return "depth-" + strconv.Itoa(depth)
+	}
+}
+
+// IsShallow reports whether the store entry of the source lacks part of its history.
+func (s Source) IsShallow() (bool, error) {
+	repo, err := git.PlainOpen(s.DestPath())
+	if err != nil {
+		return false, fmt.Errorf("failed to open repository: %w", err)
+	}
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return false, err
+	}
+	return len(shallow) != 0, nil
+}
+
+// Deepen downloads more history of the source into its mirror and creates the store entry for the new depth,
+// 0 meaning the full history. The entry is created at the commit of the current one.
+// The config is updated and the link is replaced, the config is not saved.
+// The previous entry is deleted unless another project uses it.
+func (c *Config) Deepen(source string, depth int) (Source, error) {
+	i, err := c.index(source)
+	if err != nil {
+		return Source{}, err
+	}
+	old := c.Sources[i]
+	if IsCommit(old.ReferenceName) {
+		return old, fmt.Errorf("%s is a commit, it always has the full history", old.ReferenceName)
+	}
+	if depth != 0 && depth <= old.CloneDepth() && old.CloneDepth() != 0 {
+		return old, fmt.Errorf("%s already has a depth of %d", old.ShortName(), old.CloneDepth())
+	}
+	shallow, err := old.IsShallow()
+	if err != nil {
+		return old, err
+	}
+	if !shallow {
+		return old, fmt.Errorf("%s already has the full history", old.ShortName())
+	}
+
+	repo, err := git.PlainOpen(old.DestPath())
+	if err != nil {
+		return old, fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return old, fmt.Errorf("failed to get head: %w", err)
+	}
+	remote, err := ListRemote(old.CanonicalUrl())
+	if err != nil {
+		return old, err
+	}
+	ref, err := remote.Resolve(old.canonical())
+	if err != nil {
+		return old, err
+	}
+	ref.Hash = head.Hash()
+
+	deepened := old
+	deepened.Depth = &depth
+	if err := deepened.download(c.fetcher(), ref, nil); err != nil {
+		return old, err
+	}
+	if repo, err = git.PlainOpen(deepened.DestPath()); err != nil {
+		return old, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if head, err := repo.Head(); err != nil {
+		return old, fmt.Errorf("failed to get head: %w", err)
+	} else if head.Hash() != ref.Hash {
+		// another project already created the entry at a different commit
+		return old, fmt.Errorf("%s is at %s instead of %s, run vend sync instead", deepened.DestPath(), head.Hash(), ref.Hash)
+	}
+	c.Sources[i] = deepened
+	if err := relink(deepened); err != nil {
+		return deepened, err
+	}
+
+	if referenced, err := c.IsReferenced(old.DestPath()); err == nil && !referenced {
+		if err := old.Purge(); err != nil {
+			return deepened, fmt.Errorf("failed to delete previous store entry: %w", err)
+		}
+	}
+	return deepened, nil
+}
+
+// relink points the link of the source to its store entry.
+func relink(source Source) error {
+	link, err := filepath.Abs(source.LinkPath())
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(link); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove link %s: %w", link, err)
+	}
+	return sudo.Link([]sudo.LinkData{{Old: source.DestPath(), New: link}})
+}
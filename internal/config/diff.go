@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type (
+	LogEntry struct {
+		Hash    string
+		Author  string
+		Date    time.Time
+		Subject string
+	}
+
+	// Patch is a diff between two commits, limited to the files matching the path globs.
+	Patch struct {
+		filePatches []fdiff.FilePatch
+		Stats       object.FileStats
+	}
+)
+
+func (p *Patch) FilePatches() []fdiff.FilePatch {
+	return p.filePatches
+}
+
+func (p *Patch) Message() string {
+	return ""
+}
+
+// Encode writes the patch in the unified diff format.
+func (p *Patch) Encode(w io.Writer) error {
+	return fdiff.NewUnifiedEncoder(w, fdiff.DefaultContextLines).Encode(p)
+}
+
+// ResolveCommit resolves a reference name or (abbreviated) commit hash in the repository.
+func ResolveCommit(repo *git.Repository, ref string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", ref, err)
+	}
+	return commit, nil
+}
+
+// MatchPaths reports whether the file matches one of the globs.
+// A glob matches the full path, the base name or a parent directory of the file.
+// Without globs every file matches.
+func MatchPaths(globs []string, name string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, glob := range globs {
+		glob = strings.TrimSuffix(glob, "/")
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, path.Base(name)); ok {
+			return true
+		}
+		for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if ok, _ := path.Match(glob, dir); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Log lists the commits that are reachable from to but not from from, newest first.
+// With globs, only commits touching matching files are listed.
+func Log(repo *git.Repository, from, to *object.Commit, globs []string) ([]LogEntry, error) {
+	excluded := make(map[plumbing.Hash]bool)
+	if from != nil {
+		iter, err := repo.Log(&git.LogOptions{From: from.Hash})
+		if err != nil {
+			return nil, err
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			excluded[c.Hash] = true
+			return nil
+		})
+		if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, err
+		}
+	}
+
+	opts := &git.LogOptions{From: to.Hash}
+	if len(globs) != 0 {
+		opts.PathFilter = func(name string) bool { return MatchPaths(globs, name) }
+	}
+	iter, err := repo.Log(opts)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LogEntry
+	err = iter.ForEach(func(c *object.Commit) error {
+		if excluded[c.Hash] {
+			return nil
+		}
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		entries = append(entries, LogEntry{
+			Hash:    c.Hash.String(),
+			Author:  c.Author.Name,
+			Date:    c.Author.When,
+			Subject: subject,
+		})
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Diff computes the changes between two commits, limited to the files matching the globs.
+func Diff(from, to *object.Commit, globs []string) (*Patch, error) {
+	patch, err := from.Patch(to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute diff: %w", err)
+	}
+	p := &Patch{}
+	for _, fp := range patch.FilePatches() {
+		before, after := fp.Files()
+		name := ""
+		if after != nil {
+			name = after.Path()
+		} else if before != nil {
+			name = before.Path()
+		}
+		if MatchPaths(globs, name) {
+			p.filePatches = append(p.filePatches, fp)
+		}
+	}
+	for _, stat := range patch.Stats() {
+		if MatchPaths(globs, stat.Name) {
+			p.Stats = append(p.Stats, stat)
+		}
+	}
+	return p, nil
+}
@@ -1,17 +1,20 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"vend/internal/blobs"
+	"vend/internal/user"
 
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
 )
 
 type (
@@ -197,7 +200,7 @@ func (m model) View() string {
 }
 
 // This function is critical for understanding the full clone flow
-func cloneRepository(source Source, index int, progressCh chan<- any, doneCh chan<- doneMsg) {
+func cloneRepository(f fetcher, source Source, index int, progressCh chan<- any, doneCh chan<- doneMsg) {
 	dest := source.DestPath()
 
 	// Check if repository already exists
@@ -212,26 +215,45 @@ func cloneRepository(source Source, index int, progressCh chan<- any, doneCh cha
 		progressCh: progressCh,
 	}
 
-	// Clone the repository with submodules
-	referenceName := plumbing.ReferenceName(source.ReferenceName)
-
 	// Send initial status message
 	progressCh <- progressMsg{Index: index, Percent: 0.0}
 
-	// We specifically enable recursive submodule cloning, which will trigger submodule operations
-	_, err := git.PlainClone(dest, false, &git.CloneOptions{
-		URL:               source.Url,
-		Depth:             1,
-		ReferenceName:     referenceName,
-		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
-		Progress:          progress,
-	})
+	refs, err := ListRemote(source.CanonicalUrl())
+	if err != nil {
+		doneCh <- doneMsg{Index: index, Error: err}
+		return
+	}
+	resolved, err := refs.Resolve(source.canonical())
+	if err != nil {
+		doneCh <- doneMsg{Index: index, Error: err}
+		return
+	}
+
+	err = source.download(f, resolved, progress)
 
 	// Only mark as done after all operations, including submodules, are complete
 	doneCh <- doneMsg{Index: index, Error: err}
 }
 
-func CloneMultiple(sources []Source) error {
+// populate downloads the submodules and LFS objects of a fresh checkout of the source
+// and moves its files into the blob pool of the store.
+// Submodules are updated separately from the clone to apply the submodule policy of the source.
+func (s Source) populate(progress io.Writer) error {
+	repo, err := git.PlainOpen(s.DestPath())
+	if err != nil {
+		return err
+	}
+	if err := s.updateSubmodules(repo, progress); err != nil {
+		return err
+	}
+	if err := s.fetchLFS(progress); err != nil {
+		return err
+	}
+	return blobs.New(user.Location()).Deduplicate(s.DestPath())
+}
+
+// CloneMultiple downloads the sources that are not in the store yet, showing their progress.
+func (c *Config) CloneMultiple(sources []Source) error {
 	if len(sources) == 0 {
 		return nil
 	}
@@ -260,13 +282,15 @@ func CloneMultiple(sources []Source) error {
 	// Start the bubbletea program
 	p := tea.NewProgram(m)
 
+	f := c.fetcher()
+
 	// Start the goroutines to clone repositories in parallel
 	var wg sync.WaitGroup
 	for i, source := range sources {
 		wg.Add(1)
 		go func(src Source, idx int) {
 			defer wg.Done()
-			cloneRepository(src, idx, progressCh, doneCh)
+			cloneRepository(f, src, idx, progressCh, doneCh)
 		}(source, i)
 	}
 
@@ -295,16 +319,21 @@ func CloneMultiple(sources []Source) error {
 	}()
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		return err
 	}
+	m = final.(model)
+	if m.quitting {
+		return errors.New("download interrupted")
+	}
 
 	// Check for errors
-	for _, repo := range repos {
+	var errs []error
+	for _, repo := range m.repos {
 		if repo.err != nil {
-			return repo.err
+			errs = append(errs, fmt.Errorf("%s: %w", repo.source.Url, repo.err))
 		}
 	}
-
-	return nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"vend/internal/network"
+	"vend/internal/settings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	// BackendGoGit clones with the built-in git implementation.
+	BackendGoGit = "go-git"
+	// BackendGit clones with the git binary, falling back to go-git if it isn't installed.
+	BackendGit = "git"
+)
+
+// fetcher downloads references into the mirror of a source, the store entries are checked out from there.
+type fetcher interface {
+	// fetch updates the refspecs of the mirror from the URL of the source.
+	// depth limits the history of new commits, 0 meaning all of it.
+	fetch(source Source, specs []gitconfig.RefSpec, depth int, progress io.Writer) error
+	// checkout fills the worktree of the new store entry of the source, whose objects are linked from the mirror,
+	// with the commit. branch is checked out if it's not empty.
+	checkout(source Source, branch plumbing.ReferenceName, hash plumbing.Hash, progress io.Writer) error
+}
+
+// fetcher returns the backend selected by the project or the global settings.
+func (c *Config) fetcher() fetcher {
+	backend := c.GitBackend
+	if backend == "" {
+		if s, err := settings.Get(); err == nil {
+			backend = s.GitBackend
+		}
+	}
+	if backend == BackendGit {
+		if path, err := exec.LookPath("git"); err == nil {
+			return systemGit{path: path}
+		}
+	}
+	return goGit{}
+}
+
+func validBackend(backend string) bool {
+	return backend == "" || backend == BackendGoGit || backend == BackendGit
+}
+
+// sparseKey distinguishes the store entries of the same reference with different sparse directories.
+func (s Source) sparseKey() string {
+	if len(s.Sparse) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(s.Sparse, "\x00")))
+	return "sparse-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// goGit fetches with the built-in git implementation.
+type goGit struct{}
+
+func (goGit) fetch(source Source, specs []gitconfig.RefSpec, depth int, progress io.Writer) error {
+	repo, err := git.PlainOpen(source.MirrorPath())
+	if err != nil {
+		return err
+	}
+	err = repo.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   specs,
+		Depth:      depth,
+		Tags:       git.NoTags,
+		Force:      true,
+		Progress:   progress,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+func (goGit) checkout(source Source, branch plumbing.ReferenceName, hash plumbing.Hash, progress io.Writer) error {
+	repo, err := git.PlainOpen(source.DestPath())
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	opts := &git.CheckoutOptions{Hash: hash, SparseCheckoutDirectories: source.Sparse}
+	if branch != "" {
+		opts = &git.CheckoutOptions{Branch: branch, SparseCheckoutDirectories: source.Sparse}
+	}
+	return wt.Checkout(opts)
+}
+
+// systemGit fetches with the git binary, which supports every protocol and credential helper of the git installation.
+// The mirror is a partial clone without file contents, the store entries download the contents
+// of the files they check out, so sparse entries only download their directories.
+type systemGit struct {
+	path string
+}
+
+func (g systemGit) fetch(source Source, specs []gitconfig.RefSpec, depth int, progress io.Writer) error {
+	args := []string{"-C", source.MirrorPath(), "fetch", "--progress", "--no-tags", "--filter=blob:none"}
+	if depth != 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	args = append(args, git.DefaultRemoteName)
+	for _, spec := range specs {
+		args = append(args, spec.String())
+	}
+	return g.remote(source, nil, progress, args...)
+}
+
+func (g systemGit) checkout(source Source, branch plumbing.ReferenceName, hash plumbing.Hash, progress io.Writer) error {
+	dest := source.DestPath()
+	// the missing file contents are downloaded from the URL of the source like in a partial clone
+	steps := [][]string{
+		{"-C", dest, "config", "core.repositoryformatversion", "1"},
+		{"-C", dest, "config", "remote." + git.DefaultRemoteName + ".promisor", "true"},
+		{"-C", dest, "config", "remote." + git.DefaultRemoteName + ".partialclonefilter", "blob:none"},
+	}
+	if len(source.Sparse) != 0 {
+		// patterns instead of cone mode, which would also check out the files in the root like go-git doesn't
+		args := []string{"-C", dest, "sparse-checkout", "set", "--no-cone", "--"}
+		for _, dir := range source.Sparse {
+			args = append(args, "/"+strings.Trim(dir, "/")+"/")
+		}
+		steps = append(steps, args)
+	}
+	if branch != "" {
+		steps = append(steps, []string{"-C", dest, "checkout", "--progress", branch.Short(), "--"})
+	} else {
+		steps = append(steps, []string{"-C", dest, "checkout", "--progress", "--detach", hash.String(), "--"})
+	}
+	for _, args := range steps {
+		if err := g.remote(source, nil, progress, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchObjects downloads the objects into the partial mirror of the source, like git does for missing objects.
+func (g systemGit) fetchObjects(source Source, hashes []plumbing.Hash) error {
+	var stdin strings.Builder
+	for _, h := range hashes {
+		stdin.WriteString(h.String() + "\n")
+	}
+	return g.remote(source, strings.NewReader(stdin.String()), nil,
+		"-C", source.MirrorPath(), "-c", "fetch.negotiationAlgorithm=noop", "fetch", git.DefaultRemoteName,
+		"--no-tags", "--no-write-fetch-head", "--recurse-submodules=no", "--filter=blob:none", "--stdin")
+}
+
+// remote runs git with the certificate pinned for the host of the source, if any.
+// The options are passed on to the git processes that download missing objects.
+func (g systemGit) remote(source Source, stdin io.Reader, progress io.Writer, args ...string) error {
+	pins, err := pinnedKeys(source.CanonicalUrl())
+	if err != nil {
+		return err
+	}
+	return g.run(stdin, progress, append(pins, args...)...)
+}
+
+// pinnedKeys returns the git options that enforce the certificate pinned for the host of the URL.
+// As with go-git only the pinned key is checked, so self-signed certificates are accepted.
+func pinnedKeys(rawURL string) ([]string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return nil, nil
+	}
+	keys, err := network.PinnedPublicKeys(u.Hostname())
+	if err != nil || keys == "" {
+		return nil, err
+	}
+	return []string{"-c", "http.sslVerify=false", "-c", "http.pinnedPubkey=" + keys}, nil
+}
+
+func (g systemGit) run(stdin io.Reader, progress io.Writer, args ...string) error {
+	if s, err := settings.Get(); err == nil && s.CABundle != "" {
+		args = append([]string{"-c", "http.sslCAInfo=" + s.CABundle}, args...)
+	}
+	out := &progressLines{w: progress}
+	cmd := exec.Command(g.path, args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdin = stdin
+	cmd.Stdout = io.Discard
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		if out.last != "" {
+			return fmt.Errorf("git %s: %s", gitCommand(args), out.last)
+		}
+		return fmt.Errorf("git %s: %w", gitCommand(args), err)
+	}
+	return nil
+}
+
+// gitCommand returns the subcommand of the git arguments for error messages.
+func gitCommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-C", "-c":
+			i++
+		default:
+			return args[i]
+		}
+	}
+	return ""
+}
+
+// progressLines forwards the progress output of git line by line.
+// Progress updates are separated by carriage returns instead of newlines.
+type progressLines struct {
+	w    io.Writer
+	buf  []byte
+	last string
+}
+
+func (p *progressLines) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexAny(p.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(p.buf[:i])); line != "" {
+			p.last = line
+			if p.w != nil {
+				_, _ = io.WriteString(p.w, line+"\n")
+			}
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
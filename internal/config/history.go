@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/goccy/go-yaml"
+)
+
+type (
+	// History lists the states of the project after successful syncs, oldest first.
+	History struct {
+		Entries []HistoryEntry `yaml:"entries"`
+	}
+
+	HistoryEntry struct {
+		Time         time.Time      `yaml:"time"`
+		ConfigDigest string         `yaml:"config_digest"`
+		Config       string         `yaml:"config"`
+		Sources      []LockedSource `yaml:"sources"`
+	}
+)
+
+const (
+	historyFileName = "vend.history"
+	maxHistory      = 100
+)
+
+func (c *Config) HistoryLocation() string {
+	return filepath.Join(filepath.Dir(c.Location), historyFileName)
+}
+
+// LoadHistory reads the history file next to the config file.
+// A missing history file results in an empty history.
+func (c *Config) LoadHistory() (*History, error) {
+	h := &History{Entries: []HistoryEntry{}}
+	f, err := os.Open(c.HistoryLocation())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return h, nil
+		}
+		return h, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+	if err := yaml.NewDecoder(f).Decode(h); err != nil {
+		return h, fmt.Errorf("failed to decode history file: %w", err)
+	}
+	return h, nil
+}
+
+func (c *Config) saveHistory(h *History) error {
+	f, err := os.Create(c.HistoryLocation())
+	if err != nil {
+		return fmt.Errorf("failed to create history file: %w", err)
+	}
+	defer f.Close()
+	if err := yaml.NewEncoder(f).Encode(h); err != nil {
+		return fmt.Errorf("failed to encode history file: %w", err)
+	}
+	return nil
+}
+
+// record appends the current state to the history unless it equals the latest entry.
+func (c *Config) record(lock *Lock) error {
+	data, err := os.ReadFile(c.Location)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	h, err := c.LoadHistory()
+	if err != nil {
+		return err
+	}
+	entry := HistoryEntry{
+		Time:         time.Now().UTC().Truncate(time.Second),
+		ConfigDigest: configDigest(data),
+		Config:       string(data),
+		Sources:      lock.Sources,
+	}
+	if n := len(h.Entries); n > 0 && h.Entries[n-1].equal(entry) {
+		return nil
+	}
+	h.Entries = append(h.Entries, entry)
+	if len(h.Entries) > maxHistory {
+		h.Entries = h.Entries[len(h.Entries)-maxHistory:]
+	}
+	return c.saveHistory(h)
+}
+
+func configDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (e HistoryEntry) equal(other HistoryEntry) bool {
+	return e.ConfigDigest == other.ConfigDigest && slices.EqualFunc(e.Sources, other.Sources, func(a, b LockedSource) bool {
+		return a.Url == b.Url && a.ReferenceName == b.ReferenceName && a.Commit == b.Commit
+	})
+}
+
+// Entry returns the n-th most recent entry, 0 being the current state.
+func (h *History) Entry(n int) (HistoryEntry, error) {
+	if n < 0 || n >= len(h.Entries) {
+		return HistoryEntry{}, fmt.Errorf("no history entry %d, history has %d entries", n, len(h.Entries))
+	}
+	return h.Entries[len(h.Entries)-1-n], nil
+}
+
+// Rollback restores the config and lock file of the given entry and syncs them.
+// The store entries of all sources are checked out at the recorded commits before anything is written,
+// it fails if an entry exists at another commit, e.g. because its branch moved.
+// If the sync fails, the previous config and lock file are restored.
+func (c *Config) Rollback(entry HistoryEntry) (*Config, error) {
+	restored, err := Decode(bytes.NewReader([]byte(entry.Config)))
+	if err != nil {
+		return nil, err
+	}
+	restored.Location = c.Location
+	lock := &Lock{Version: 1, Sources: entry.Sources}
+	for i, s := range restored.Sources {
+		if locked, ok := lock.Find(s); ok && locked.ReferenceName == s.ReferenceName {
+			restored.Sources[i].canonicalRef = locked.CanonicalRef
+		}
+	}
+
+	var errs []error
+	for _, source := range restored.resolvedWith(lock) {
+		locked, ok := lock.Find(source)
+		if !ok || locked.Commit == "" {
+			continue
+		}
+		if err := restored.restoreEntry(source, locked.Commit); err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", source.Url, err))
+		}
+	}
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	previousConfig, err := os.ReadFile(c.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	previousLock, err := os.ReadFile(c.LockLocation())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	restore := func(err error) (*Config, error) {
+		errs := []error{err}
+		if err := os.WriteFile(c.Location, previousConfig, 0644); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore config file: %w", err))
+		}
+		if previousLock == nil {
+			_ = os.Remove(c.LockLocation())
+		} else if err := os.WriteFile(c.LockLocation(), previousLock, 0644); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore lock file: %w", err))
+		}
+		if previous, err := LoadFrom(c.Location); err == nil {
+			if err := previous.Sync(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to sync the previous state: %w", err))
+			}
+		}
+		return c, errors.Join(errs...)
+	}
+
+	if err := os.WriteFile(c.Location, []byte(entry.Config), 0644); err != nil {
+		return restore(fmt.Errorf("failed to write config file: %w", err))
+	}
+	if err := restored.SaveLock(lock); err != nil {
+		return restore(err)
+	}
+	if err := restored.Sync(); err != nil {
+		return restore(err)
+	}
+
+	synced, err := restored.LoadLock()
+	if err != nil {
+		return restore(err)
+	}
+	for _, want := range entry.Sources {
+		got, ok := synced.find(want.Url)
+		if !ok {
+			errs = append(errs, fmt.Errorf("source %s is missing after rollback", want.Url))
+		} else if got.Commit != want.Commit {
+			errs = append(errs, fmt.Errorf("source %s is at %s instead of %s", want.Url, got.Commit, want.Commit))
+		}
+	}
+	if len(errs) != 0 {
+		return restore(errors.Join(errs...))
+	}
+	return restored, nil
+}
+
+// restoreEntry makes sure the store entry of the source is checked out at the commit.
+func (c *Config) restoreEntry(source Source, commit string) error {
+	hash := plumbing.NewHash(commit)
+	if _, err := os.Stat(source.DestPath()); err == nil {
+		repo, err := git.PlainOpen(source.DestPath())
+		if err != nil {
+			return fmt.Errorf("failed to open repository: %w", err)
+		}
+		head, err := repo.Head()
+		if err != nil {
+			return fmt.Errorf("failed to get head: %w", err)
+		}
+		if head.Hash() != hash {
+			return fmt.Errorf("store entry %s is at %s instead of the recorded %s, its reference moved", source.DestPath(), head.Hash(), commit)
+		}
+		return nil
+	}
+
+	// the reference may have moved since, fetch the recorded commit itself
+	if _, err := source.FetchRefs(nil, commit); err != nil {
+		return err
+	}
+	name := plumbing.ReferenceName(source.canonical())
+	ref := ResolvedRef{Kind: RefCommit, Name: name, Hash: hash}
+	switch {
+	case name.IsTag():
+		ref.Kind = RefTag
+	case name.IsBranch():
+		ref.Kind = RefBranch
+	}
+	return source.download(c.fetcher(), ref, nil)
+}
+
+func (l *Lock) find(url string) (LockedSource, bool) {
+	for _, ls := range l.Sources {
+		if ls.Url == url {
+			return ls, true
+		}
+	}
+	return LockedSource{}, false
+}
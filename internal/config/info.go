@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+type CommitInfo struct {
+	Hash        string
+	Author      string
+	AuthorEmail string
+	Date        time.Time
+	Message     string
+	// TagMessage is the message of the annotated tag the source references, if any.
+	TagMessage string
+}
+
+// DirSize returns the size of all files in dir.
+func DirSize(dir string) (uint64, error) {
+	var size uint64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += uint64(fi.Size())
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Commit reads the checked out commit of the store entry.
+func (s Source) Commit() (CommitInfo, error) {
+	info := CommitInfo{}
+	repo, err := git.PlainOpen(s.DestPath())
+	if err != nil {
+		return info, fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return info, fmt.Errorf("failed to get head: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return info, fmt.Errorf("failed to get commit: %w", err)
+	}
+	info.Hash = commit.Hash.String()
+	info.Author = commit.Author.Name
+	info.AuthorEmail = commit.Author.Email
+	info.Date = commit.Committer.When
+	info.Message = strings.TrimSpace(commit.Message)
+
+	if tagRef, err := repo.Tag(strings.TrimPrefix(s.ReferenceName, "refs/tags/")); err == nil {
+		if tag, err := repo.TagObject(tagRef.Hash()); err == nil {
+			info.TagMessage = strings.TrimSpace(tag.Message)
+		}
+	}
+	return info, nil
+}
+
+var (
+	readmeRE     = regexp.MustCompile(`(?i)^readme(\.(md|markdown|txt|rst))?$`)
+	markdownLink = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// ReadmeSummary returns the first paragraph of the README of the store entry.
+func (s Source) ReadmeSummary() string {
+	entries, err := os.ReadDir(s.DestPath())
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !readmeRE.MatchString(entry.Name()) {
+			continue
+		}
+		f, err := os.Open(filepath.Join(s.DestPath(), entry.Name()))
+		if err != nil {
+			return ""
+		}
+		defer f.Close()
+		return firstParagraph(bufio.NewScanner(f))
+	}
+	return ""
+}
+
+func firstParagraph(scanner *bufio.Scanner) string {
+	var paragraph []string
+	inCode := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if (strings.HasPrefix(line, "===") || strings.HasPrefix(line, "---")) && len(paragraph) != 0 {
+			// the previous line was a setext heading
+			paragraph = paragraph[:len(paragraph)-1]
+			continue
+		}
+		isText := line != "" &&
+			!strings.HasPrefix(line, "#") &&
+			!strings.HasPrefix(line, "<") &&
+			!strings.HasPrefix(line, "[![") &&
+			!strings.HasPrefix(line, "![") &&
+			!strings.HasPrefix(line, "===") &&
+			!strings.HasPrefix(line, "---")
+		if !isText {
+			if len(paragraph) != 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, markdownLink.ReplaceAllString(line, "$1"))
+	}
+	summary := []rune(strings.Join(paragraph, " "))
+	if len(summary) > 300 {
+		return string(summary[:297]) + "..."
+	}
+	return string(summary)
+}
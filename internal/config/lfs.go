@@ -0,0 +1,63 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"vend/internal/lfs"
+	"vend/internal/network"
+)
+
+// LFSOptions limits which Git LFS objects of a source are downloaded.
+// Paths that match no include or any exclude glob stay pointer files.
+type LFSOptions struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+func (o *LFSOptions) includes(name string) bool {
+	if o == nil {
+		return true
+	}
+	if len(o.Exclude) != 0 && MatchPaths(o.Exclude, name) {
+		return false
+	}
+	return MatchPaths(o.Include, name)
+}
+
+// key distinguishes the store entries of the same reference with different LFS objects.
+func (o *LFSOptions) key() string {
+	if o == nil || (len(o.Include) == 0 && len(o.Exclude) == 0) {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", strings.Join(o.Include, "\x00"), strings.Join(o.Exclude, "\x00"))
+	return "lfs-" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// fetchLFS replaces the LFS pointer files of the store entry with their objects.
+func (s Source) fetchLFS(progress io.Writer) error {
+	dir := s.DestPath()
+	pointers, err := lfs.Scan(dir, s.LFS.includes)
+	if err != nil {
+		return fmt.Errorf("failed to scan for LFS files: %w", err)
+	}
+	if len(pointers) == 0 {
+		return nil
+	}
+	ep, err := lfs.ResolveEndpoint(dir, s.CanonicalUrl())
+	if err != nil {
+		return err
+	}
+	client, err := network.Client()
+	if err != nil {
+		return fmt.Errorf("error setting up network: %w", err)
+	}
+	return lfs.Fetch(client, ep, dir, pointers, func(done, total int) {
+		if progress != nil {
+			fmt.Fprintf(progress, "Downloading LFS objects: %d%% (%d/%d)\n", done*100/total, done, total)
+		}
+	})
+}
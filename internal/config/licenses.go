@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"vend/internal/license"
+)
+
+type LicensePolicy struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+}
+
+// Licenses detects the licenses in the store entry of the source.
+func (s Source) Licenses() ([]license.License, error) {
+	return license.Detect(s.DestPath())
+}
+
+// CheckLicenses checks the store entries of all sources against the license policy.
+func (c *Config) CheckLicenses() error {
+	sources, err := c.Resolved()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, source := range sources {
+		if err := c.checkLicense(source); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) checkLicense(source Source) error {
+	if c.LicensePolicy == nil {
+		return nil
+	}
+	licenses, err := source.Licenses()
+	if err != nil {
+		return err
+	}
+	return c.LicensePolicy.Check(license.IDs(licenses))
+}
+
+// Check returns an error if one of the given license identifiers violates the policy.
+// If an allow list is set, every license has to be on it and at least one license has to be detected.
+func (p *LicensePolicy) Check(ids []string) error {
+	if len(p.Allow) != 0 && len(ids) == 0 {
+		return errors.New("no known license detected")
+	}
+	var errs []error
+	for _, id := range ids {
+		matches := func(policyID string) bool { return license.Matches(policyID, id) }
+		if slices.ContainsFunc(p.Deny, matches) {
+			errs = append(errs, fmt.Errorf("license %s is denied", id))
+		} else if len(p.Allow) != 0 && !slices.ContainsFunc(p.Allow, matches) {
+			errs = append(errs, fmt.Errorf("license %s is not allowed", id))
+		}
+	}
+	return errors.Join(errs...)
+}
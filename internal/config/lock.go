@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-yaml"
+)
+
+type (
+	// Lock records the exact state of every source after a successful sync.
+	Lock struct {
+		Version uint           `yaml:"version"`
+		Sources []LockedSource `yaml:"sources"`
+	}
+
+	LockedSource struct {
+		Url           string `yaml:"url"`
+		ReferenceName string `yaml:"reference_name"`
+		// CanonicalRef is the full reference name of a tag or branch or the full hash of a commit.
+		CanonicalRef string `yaml:"canonical_ref,omitempty"`
+		Commit       string `yaml:"commit"`
+		ContentHash  string `yaml:"content_hash,omitempty"`
+		Signer       string `yaml:"signer,omitempty"`
+		// Name is the link name of a transitive source.
+		Name       string   `yaml:"name,omitempty"`
+		Transitive bool     `yaml:"transitive,omitempty"`
+		RequiredBy []string `yaml:"required_by,omitempty"`
+		// Submodules, Depth, LFS and Sparse are the options of a transitive source, they determine its store entry.
+		Submodules *SubmodulePolicy `yaml:"submodules,omitempty"`
+		Depth      *int             `yaml:"depth,omitempty"`
+		LFS        *LFSOptions      `yaml:"lfs,omitempty"`
+		Sparse     []string         `yaml:"sparse,omitempty"`
+	}
+)
+
+const lockFileName = "vend.lock"
+
+func (c *Config) LockLocation() string {
+	return filepath.Join(filepath.Dir(c.Location), lockFileName)
+}
+
+// LoadLock reads the lock file next to the config file.
+// A missing lock file results in an empty lock.
+func (c *Config) LoadLock() (*Lock, error) {
+	l := &Lock{Version: 1, Sources: []LockedSource{}}
+	f, err := os.Open(c.LockLocation())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return l, nil
+		}
+		return l, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer f.Close()
+	return DecodeLock(f)
+}
+
+// DecodeLock reads a lock from r.
+func DecodeLock(r io.Reader) (*Lock, error) {
+	l := &Lock{Version: 1, Sources: []LockedSource{}}
+	if err := yaml.NewDecoder(r).Decode(l); err != nil {
+		return l, fmt.Errorf("failed to decode lock file: %w", err)
+	}
+	return l, nil
+}
+
+func (c *Config) SaveLock(l *Lock) error {
+	if c.Location == "" {
+		return fmt.Errorf("config location not set")
+	}
+	f, err := os.Create(c.LockLocation())
+	if err != nil {
+		return fmt.Errorf("failed to create lock file: %w", err)
+	}
+	defer f.Close()
+	if err := yaml.NewEncoder(f).Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	return nil
+}
+
+// Find returns the locked state of the given source.
+func (l *Lock) Find(s Source) (LockedSource, bool) {
+	for _, ls := range l.Sources {
+		if ls.Url == s.CanonicalUrl() || ls.Url == s.Url {
+			return ls, true
+		}
+	}
+	return LockedSource{}, false
+}
+
+// Remove drops the locked state of the given source.
+func (l *Lock) Remove(s Source) {
+	for i, ls := range l.Sources {
+		if ls.Url == s.CanonicalUrl() || ls.Url == s.Url {
+			l.Sources = append(l.Sources[:i], l.Sources[i+1:]...)
+			return
+		}
+	}
+}
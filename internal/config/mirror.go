@@ -0,0 +1,585 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"vend/internal/filelock"
+	"vend/internal/user"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const mirrorDirName = ".mirror"
+
+// MirrorPath is the bare repository with the full history of the URL of the source.
+// It is shared by all references of the same URL.
+func (s Source) MirrorPath() string {
+	return filepath.Join(user.Location(), s.repoName(), mirrorDirName)
+}
+
+// infiniteDepth asks the server for the complete history of a shallow repository, like git fetch --unshallow.
+const infiniteDepth = 0x7fffffff
+
+// mirrorRefSpecs fetch all branches and tags.
+var mirrorRefSpecs = []gitconfig.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"}
+
+// lockMirror serializes access to the mirror of the source between goroutines and vend processes.
+// Store entries of the URL are created while the lock is held.
+func (s Source) lockMirror() (func(), error) {
+	return filelock.Lock(s.MirrorPath() + ".lock")
+}
+
+// openMirror opens the mirror of the source, an empty mirror is created if it doesn't exist yet.
+func (s Source) openMirror() (*git.Repository, error) {
+	path := s.MirrorPath()
+	repo, err := git.PlainOpen(path)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create mirror directory: %w", err)
+		}
+		repo, err = git.PlainInit(path, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mirror: %w", err)
+		}
+		_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+			Name:  git.DefaultRemoteName,
+			URLs:  []string{s.CanonicalUrl()},
+			Fetch: mirrorRefSpecs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure mirror: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open mirror: %w", err)
+	}
+	return repo, nil
+}
+
+// Mirror fetches all branches and tags with their full history into the mirror of the source.
+func (s Source) Mirror(progress io.Writer) (*git.Repository, error) {
+	unlock, err := s.lockMirror()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	repo, err := s.openMirror()
+	if err != nil {
+		return nil, err
+	}
+	return s.fetchMirror(goGit{}, repo, nil, 0, progress)
+}
+
+// FetchRefs makes sure the mirror of the source contains the given references with their full history.
+// Only the references that are missing are fetched. Names that aren't known to the remote are looked up as commits.
+func (s Source) FetchRefs(progress io.Writer, names ...string) (*git.Repository, error) {
+	remote, err := ListRemote(s.CanonicalUrl())
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]ResolvedRef, 0, len(names))
+	for _, name := range names {
+		ref, err := remote.Resolve(name)
+		if err != nil {
+			if errors.Is(err, ErrAmbiguousRef) || !IsCommit(name) {
+				return nil, err
+			}
+			ref = ResolvedRef{Kind: RefCommit, Name: plumbing.ReferenceName(name)}
+		}
+		refs = append(refs, ref)
+	}
+
+	unlock, err := s.lockMirror()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	repo, err := s.openMirror()
+	if err != nil {
+		return nil, err
+	}
+	return s.fetchMirror(goGit{}, repo, refs, 0, progress)
+}
+
+// fetchMirror downloads the given references with at least depth commits of history into the mirror,
+// 0 meaning the full history. References that are already there are not fetched again.
+// Without references, all branches and tags are fetched. The caller holds the mirror lock.
+// The mirror is opened again after fetching, the fetcher may have written to it behind the back of repo.
+func (s Source) fetchMirror(f fetcher, repo *git.Repository, refs []ResolvedRef, depth int, progress io.Writer) (*git.Repository, error) {
+	f, err := mirrorFetcher(f, repo)
+	if err != nil {
+		return repo, err
+	}
+	fetch := func(specs []gitconfig.RefSpec, depth int) error {
+		shallow, err := repo.Storer.Shallow()
+		if err != nil {
+			return err
+		}
+		if depth == 0 && len(shallow) != 0 {
+			depth = infiniteDepth
+		}
+		if err := f.fetch(s, specs, depth, progress); err != nil {
+			return fmt.Errorf("failed to fetch into mirror: %w", err)
+		}
+		if repo, err = git.PlainOpen(s.MirrorPath()); err != nil {
+			return err
+		}
+		return pruneShallow(repo)
+	}
+
+	if len(refs) == 0 {
+		return repo, fetch(mirrorRefSpecs, 0)
+	}
+	var specs []gitconfig.RefSpec
+	for _, ref := range refs {
+		if ok, err := hasRef(repo, ref, depth); err != nil {
+			return repo, err
+		} else if ok {
+			continue
+		}
+		if ref.Kind == RefCommit {
+			// commits that are not the tip of a reference can only be found in the history of all references
+			return repo, fetch(mirrorRefSpecs, 0)
+		}
+		specs = append(specs, gitconfig.RefSpec(fmt.Sprintf("+%s:%s", ref.Name, ref.Name)))
+	}
+	if len(specs) == 0 {
+		return repo, nil
+	}
+	if err := fetch(specs, depth); err != nil {
+		return repo, err
+	}
+	for _, ref := range refs {
+		if ok, err := hasRef(repo, ref, depth); err != nil {
+			return repo, err
+		} else if !ok {
+			// e.g. an older commit of a branch that moved on
+			return repo, fetch(mirrorRefSpecs, 0)
+		}
+	}
+	return repo, nil
+}
+
+// mirrorFetcher returns the backend for the mirror. A partial mirror, fetched by the git backend,
+// lacks file contents only git can download, so it is always fetched and checked out with git.
+func mirrorFetcher(f fetcher, repo *git.Repository) (fetcher, error) {
+	if _, ok := f.(systemGit); ok || !isPartial(repo) {
+		return f, nil
+	}
+	path, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("the mirror is a partial clone of the git backend, git is required: %w", err)
+	}
+	return systemGit{path: path}, nil
+}
+
+// isPartial reports whether file contents may be missing from the repository.
+func isPartial(repo *git.Repository) bool {
+	cfg, err := repo.Config()
+	if err != nil {
+		return false
+	}
+	return cfg.Raw.Section("remote").Subsection(git.DefaultRemoteName).Option("promisor") == "true"
+}
+
+// FetchBlobs downloads the file contents of the changes between two commits that are missing from the partial
+// mirror of the source, limited to the files matching the globs. Complete mirrors have all of them already.
+// It returns the commits read from the updated mirror.
+func (s Source) FetchBlobs(repo *git.Repository, from, to *object.Commit, globs []string) (*object.Commit, *object.Commit, error) {
+	if !isPartial(repo) {
+		return from, to, nil
+	}
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, nil, err
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, nil, err
+	}
+	var missing []plumbing.Hash
+	for _, change := range changes {
+		for _, entry := range []object.ChangeEntry{change.From, change.To} {
+			if entry.Name == "" || entry.TreeEntry.Mode == filemode.Submodule || !MatchPaths(globs, entry.Name) {
+				continue
+			}
+			if err := repo.Storer.HasEncodedObject(entry.TreeEntry.Hash); err != nil {
+				missing = append(missing, entry.TreeEntry.Hash)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return from, to, nil
+	}
+	f, err := mirrorFetcher(goGit{}, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlock, err := s.lockMirror()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+	if err := f.(systemGit).fetchObjects(s, missing); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch file contents into mirror: %w", err)
+	}
+	// the objects were written behind the back of repo
+	if repo, err = git.PlainOpen(s.MirrorPath()); err != nil {
+		return nil, nil, err
+	}
+	if from, err = repo.CommitObject(from.Hash); err != nil {
+		return nil, nil, err
+	}
+	if to, err = repo.CommitObject(to.Hash); err != nil {
+		return nil, nil, err
+	}
+	return from, to, nil
+}
+
+// hasRef reports whether the mirror contains the commit of the reference with at least depth commits of history.
+// Tags also need the tag reference for signature verification, branches only need the commit.
+func hasRef(repo *git.Repository, ref ResolvedRef, depth int) (bool, error) {
+	hash, err := mirrorCommit(repo, ref)
+	if err != nil {
+		return false, nil
+	}
+	if ref.Kind == RefTag {
+		tag, err := repo.Reference(ref.Name, true)
+		if err != nil {
+			return false, nil
+		}
+		if peeled, err := peel(repo, tag.Hash()); err != nil || peeled != hash {
+			return false, nil
+		}
+	}
+	shallows, err := reachableShallows(repo, hash, depth)
+	if err != nil {
+		return false, err
+	}
+	return len(shallows) == 0, nil
+}
+
+// mirrorCommit returns the commit of the reference in the mirror.
+func mirrorCommit(repo *git.Repository, ref ResolvedRef) (plumbing.Hash, error) {
+	hash := ref.Hash
+	if hash.IsZero() {
+		h, err := repo.ResolveRevision(plumbing.Revision(ref.Canonical()))
+		if err != nil {
+			return hash, fmt.Errorf("commit %s not found: %w", ref.Canonical(), err)
+		}
+		hash = *h
+	}
+	if _, err := repo.CommitObject(hash); err != nil {
+		return hash, fmt.Errorf("commit %s not found: %w", hash, err)
+	}
+	return hash, nil
+}
+
+// peel returns the commit an annotated tag points to, other hashes are returned as they are.
+func peel(repo *git.Repository, hash plumbing.Hash) (plumbing.Hash, error) {
+	tag, err := repo.TagObject(hash)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return hash, nil
+	}
+	if err != nil {
+		return hash, err
+	}
+	commit, err := tag.Commit()
+	if err != nil {
+		return hash, err
+	}
+	return commit.Hash, nil
+}
+
+// reachableShallows returns the commits within depth generations of the commit whose history is missing,
+// 0 meaning its full history. The history is complete up to depth if there are none.
+func reachableShallows(repo *git.Repository, hash plumbing.Hash, depth int) ([]plumbing.Hash, error) {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil || len(shallow) == 0 {
+		return nil, err
+	}
+	incomplete, _, err := walkHistory(repo, hash, depth, shallow)
+	return incomplete, err
+}
+
+// entryShallows returns the commits at which the history of a store entry with the given depth ends,
+// 0 meaning the full history. The history of the mirror may end earlier.
+func entryShallows(repo *git.Repository, hash plumbing.Hash, depth int) ([]plumbing.Hash, error) {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return nil, err
+	}
+	if depth == 0 && len(shallow) == 0 {
+		return nil, nil
+	}
+	incomplete, limit, err := walkHistory(repo, hash, depth, shallow)
+	return append(incomplete, limit...), err
+}
+
+// ErrIncompleteHistory is returned if neither the mirror nor the store entry have the complete history of a commit.
+var ErrIncompleteHistory = errors.New("incomplete history")
+
+// History opens the mirror or, if its history of the commit is incomplete, the store entry of the source.
+// Nothing is downloaded, use vend deepen or vend diff to fetch the history.
+func (s Source) History(hash plumbing.Hash) (*git.Repository, error) {
+	unlock, err := filelock.RLock(s.MirrorPath() + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	for _, path := range []string{s.MirrorPath(), s.DestPath()} {
+		repo, err := git.PlainOpen(path)
+		if err != nil {
+			continue
+		}
+		if _, err := repo.CommitObject(hash); err != nil {
+			continue
+		}
+		shallow, err := repo.Storer.Shallow()
+		if err != nil {
+			return nil, err
+		}
+		incomplete, _, err := walkHistory(repo, hash, 0, shallow)
+		if err != nil {
+			return nil, err
+		}
+		if len(incomplete) == 0 {
+			return repo, nil
+		}
+	}
+	return nil, fmt.Errorf("%w of %s in %s", ErrIncompleteHistory, hash, s.CanonicalUrl())
+}
+
+// walkHistory walks the history of the commit breadth first, up to depth generations (unlimited if 0).
+// incomplete are the commits before that depth that are shallow or whose parents are missing,
+// limit are the commits at that depth which have parents.
+func walkHistory(repo *git.Repository, hash plumbing.Hash, depth int, shallow []plumbing.Hash) (incomplete, limit []plumbing.Hash, err error) {
+	isShallow := make(map[plumbing.Hash]bool, len(shallow))
+	for _, h := range shallow {
+		isShallow[h] = true
+	}
+	seen := map[plumbing.Hash]bool{hash: true}
+	level := []plumbing.Hash{hash}
+	for generation := 1; len(level) != 0; generation++ {
+		var next []plumbing.Hash
+		for _, h := range level {
+			commit, err := repo.CommitObject(h)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read commit %s: %w", h, err)
+			}
+			switch {
+			case len(commit.ParentHashes) == 0:
+				continue
+			case generation == depth:
+				limit = append(limit, h)
+				continue
+			case isShallow[h] || !hasParents(repo, commit):
+				incomplete = append(incomplete, h)
+				continue
+			}
+			for _, parent := range commit.ParentHashes {
+				if !seen[parent] {
+					seen[parent] = true
+					next = append(next, parent)
+				}
+			}
+		}
+		level = next
+	}
+	return incomplete, limit, nil
+}
+
+func hasParents(repo *git.Repository, commit *object.Commit) bool {
+	for _, parent := range commit.ParentHashes {
+		if _, err := repo.Storer.EncodedObject(plumbing.CommitObject, parent); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneShallow drops the commits whose parents were fetched in the meantime from the shallow commits.
+// Fetching with a depth marks the oldest fetched commits as shallow, even if their history is already there.
+func pruneShallow(repo *git.Repository) error {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil || len(shallow) == 0 {
+		return err
+	}
+	kept := make([]plumbing.Hash, 0, len(shallow))
+	for _, h := range shallow {
+		if commit, err := repo.CommitObject(h); err != nil || !hasParents(repo, commit) {
+			kept = append(kept, h)
+		}
+	}
+	if len(kept) == len(shallow) {
+		return nil
+	}
+	return repo.Storer.SetShallow(kept)
+}
+
+// download creates the store entry of the source for the resolved reference from its mirror,
+// fetching what is missing first. ref.Hash selects the commit, e.g. an older commit of a branch.
+// Another process may have created the entry in the meantime, it is used as it is.
+func (s Source) download(f fetcher, ref ResolvedRef, progress io.Writer) error {
+	unlock, err := s.lockMirror()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := os.Stat(s.DestPath()); err == nil {
+		return nil
+	}
+	repo, err := s.openMirror()
+	if err != nil {
+		return err
+	}
+	if f, err = mirrorFetcher(f, repo); err != nil {
+		return err
+	}
+	if repo, err = s.fetchMirror(f, repo, []ResolvedRef{ref}, s.historyDepth(), progress); err != nil {
+		return err
+	}
+	hash, err := mirrorCommit(repo, ref)
+	if err != nil {
+		return err
+	}
+	name := ref.Name
+	if ref.Kind == RefCommit {
+		name = ""
+	}
+	if err := s.checkout(f, repo, name, hash, progress); err != nil {
+		return err
+	}
+	if err := s.populate(progress); err != nil {
+		_ = os.RemoveAll(s.DestPath())
+		return err
+	}
+	return nil
+}
+
+// checkout creates the store entry of the source at the given commit from its mirror.
+// ref is the tag or branch to recreate in the entry, empty for a detached commit.
+// The objects of the mirror are hard linked into the entry, so only the worktree takes space.
+// The worktree is checked out by the backend, git downloads the file contents missing from a partial mirror.
+// The history of the entry is cut off at the depth of the source, even if the mirror has more.
+// The origin of the entry points to the URL of the source, not to the mirror.
+// Store entries are never changed once they exist, so the entry must not exist yet.
+func (s Source) checkout(f fetcher, mirror *git.Repository, ref plumbing.ReferenceName, hash plumbing.Hash, progress io.Writer) error {
+	dest := s.DestPath()
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("store entry %s already exists", dest)
+	}
+	if _, err := git.PlainInit(dest, false); err != nil {
+		return fmt.Errorf("failed to create repository: %w", err)
+	}
+	cleanup := func(err error) error {
+		_ = os.RemoveAll(dest)
+		return err
+	}
+	if err := linkObjects(filepath.Join(s.MirrorPath(), "objects"), filepath.Join(dest, git.GitDirName, "objects")); err != nil {
+		return cleanup(fmt.Errorf("failed to link objects from mirror: %w", err))
+	}
+
+	repo, err := git.PlainOpen(dest)
+	if err != nil {
+		return cleanup(err)
+	}
+	shallow, err := entryShallows(mirror, hash, s.historyDepth())
+	if err != nil {
+		return cleanup(err)
+	}
+	if len(shallow) != 0 {
+		if err := repo.Storer.SetShallow(shallow); err != nil {
+			return cleanup(err)
+		}
+	}
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name:  git.DefaultRemoteName,
+		URLs:  []string{s.CanonicalUrl()},
+		Fetch: []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf(gitconfig.DefaultFetchRefSpec, git.DefaultRemoteName))},
+	})
+	if err != nil {
+		return cleanup(err)
+	}
+
+	var branch plumbing.ReferenceName
+	switch {
+	case ref.IsTag():
+		// keep annotated tags for signature verification
+		tag, err := mirror.Reference(ref, false)
+		if err != nil {
+			return cleanup(fmt.Errorf("tag %s not found in mirror: %w", ref.Short(), err))
+		}
+		if err := repo.Storer.SetReference(tag); err != nil {
+			return cleanup(err)
+		}
+	case ref.IsBranch():
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(ref, hash)); err != nil {
+			return cleanup(err)
+		}
+		remote := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref.Short())
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(remote, hash)); err != nil {
+			return cleanup(err)
+		}
+		branch = ref
+	}
+
+	if err := f.checkout(s, branch, hash, progress); err != nil {
+		return cleanup(fmt.Errorf("failed to checkout %s: %w", hash, err))
+	}
+	return nil
+}
+
+// linkObjects hard links the object files of src into dst, they are never modified.
+// Files are copied if hard links are not possible.
+func linkObjects(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if rel == "info" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if err := os.Link(path, target); err == nil || errors.Is(err, os.ErrExist) {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0444)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"vend/internal/user"
+
+	"github.com/goccy/go-yaml"
+)
+
+// registry lists the config files of all projects that synced sources into the global store.
+type registry struct {
+	Projects []string `yaml:"projects"`
+}
+
+const registryFileName = "projects.yaml"
+
+func registryLocation() string {
+	return filepath.Join(user.Location(), registryFileName)
+}
+
+func loadRegistry() (*registry, error) {
+	r := &registry{Projects: []string{}}
+	f, err := os.Open(registryLocation())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return r, nil
+		}
+		return r, fmt.Errorf("failed to open project registry: %w", err)
+	}
+	defer f.Close()
+	if err := yaml.NewDecoder(f).Decode(r); err != nil {
+		return r, fmt.Errorf("failed to decode project registry: %w", err)
+	}
+	return r, nil
+}
+
+func (r *registry) save() error {
+	if err := os.MkdirAll(user.Location(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", user.Location(), err)
+	}
+	f, err := os.Create(registryLocation())
+	if err != nil {
+		return fmt.Errorf("failed to create project registry: %w", err)
+	}
+	defer f.Close()
+	if err := yaml.NewEncoder(f).Encode(r); err != nil {
+		return fmt.Errorf("failed to encode project registry: %w", err)
+	}
+	return nil
+}
+
+// register adds the project to the registry and drops projects whose config file is gone.
+func (c *Config) register() error {
+	r, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	projects := make([]string, 0, len(r.Projects)+1)
+	for _, p := range r.Projects {
+		if _, err := os.Stat(p); err == nil && p != c.Location {
+			projects = append(projects, p)
+		}
+	}
+	r.Projects = append(projects, c.Location)
+	slices.Sort(r.Projects)
+	return r.save()
+}
+
+// Projects loads the configs of all registered projects.
+// Projects that can't be loaded anymore are skipped.
+func Projects() ([]*Config, error) {
+	r, err := loadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]*Config, 0, len(r.Projects))
+	for _, p := range r.Projects {
+		c, err := LoadFrom(p)
+		if err != nil {
+			continue
+		}
+		configs = append(configs, c)
+	}
+	return configs, nil
+}
+
+// IsReferenced reports whether any registered project uses the given store entry,
+// directly or as a transitive source recorded in its lock.
+// For this project, the sources in memory are used instead of the saved config.
+func (c *Config) IsReferenced(destPath string) (bool, error) {
+	projects, err := Projects()
+	if err != nil {
+		return false, err
+	}
+	projects = slices.DeleteFunc(projects, func(p *Config) bool {
+		return p.Location == c.Location
+	})
+	for _, p := range append(projects, c) {
+		sources, err := p.Resolved()
+		if err != nil {
+			return false, fmt.Errorf("failed to read the transitive sources of %s: %w", p.Location, err)
+		}
+		for _, s := range sources {
+			if s.DestPath() == destPath {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"vend/internal/semver"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+type (
+	RefKind int
+
+	// RemoteRefs are the references advertised by a remote repository.
+	RemoteRefs struct {
+		Url  string
+		refs map[plumbing.ReferenceName]plumbing.Hash
+		// peeled maps annotated tags to the commit they point to
+		peeled map[plumbing.ReferenceName]plumbing.Hash
+		head   plumbing.ReferenceName
+	}
+)
+
+const (
+	RefUnknown RefKind = iota
+	RefTag
+	RefBranch
+	RefCommit
+)
+
+func (k RefKind) String() string {
+	switch k {
+	case RefTag:
+		return "tag"
+	case RefBranch:
+		return "branch"
+	case RefCommit:
+		return "commit"
+	default:
+		return "unknown"
+	}
+}
+
+var commitRE = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// IsCommit reports whether the reference name looks like a (abbreviated) commit hash.
+func IsCommit(ref string) bool {
+	return commitRE.MatchString(ref)
+}
+
+// ListRemote queries the references of the remote repository without cloning it.
+func ListRemote(url string) (*RemoteRefs, error) {
+	remote := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	refs, err := remote.List(&git.ListOptions{PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references of %s: %w", url, err)
+	}
+	r := &RemoteRefs{
+		Url:    url,
+		refs:   make(map[plumbing.ReferenceName]plumbing.Hash, len(refs)),
+		peeled: make(map[plumbing.ReferenceName]plumbing.Hash),
+	}
+	for _, ref := range refs {
+		name := ref.Name()
+		if name == plumbing.HEAD {
+			if ref.Type() == plumbing.SymbolicReference {
+				r.head = ref.Target()
+			}
+			continue
+		}
+		if base, ok := strings.CutSuffix(name.String(), "^{}"); ok {
+			r.peeled[plumbing.ReferenceName(base)] = ref.Hash()
+			continue
+		}
+		r.refs[name] = ref.Hash()
+	}
+	return r, nil
+}
+
+// Tags returns the short names of all tags.
+func (r *RemoteRefs) Tags() []string {
+	return r.names(func(n plumbing.ReferenceName) bool { return n.IsTag() })
+}
+
+// Branches returns the short names of all branches.
+func (r *RemoteRefs) Branches() []string {
+	return r.names(func(n plumbing.ReferenceName) bool { return n.IsBranch() })
+}
+
+func (r *RemoteRefs) names(filter func(plumbing.ReferenceName) bool) []string {
+	names := make([]string, 0, len(r.refs))
+	for name := range r.refs {
+		if filter(name) {
+			names = append(names, name.Short())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultBranch returns the short name of the branch HEAD of the remote points to.
+func (r *RemoteRefs) DefaultBranch() string {
+	return r.head.Short()
+}
+
+// Commit returns the commit the given full reference points to.
+func (r *RemoteRefs) Commit(name plumbing.ReferenceName) (plumbing.Hash, bool) {
+	if h, ok := r.peeled[name]; ok {
+		return h, true
+	}
+	h, ok := r.refs[name]
+	return h, ok
+}
+
+// ErrAmbiguousRef is returned for names that match several references or commits.
+var ErrAmbiguousRef = errors.New("ambiguous reference")
+
+// ResolvedRef is a reference name resolved against the references of a remote.
+type ResolvedRef struct {
+	Kind RefKind
+	// Name is the full reference name of tags and branches.
+	Name plumbing.ReferenceName
+	// Hash is zero for commits that are not the tip of any reference.
+	Hash plumbing.Hash
+}
+
+// Canonical returns the full reference name of tags and branches and the hash of commits.
+func (r ResolvedRef) Canonical() string {
+	if r.Kind == RefCommit && !r.Hash.IsZero() {
+		return r.Hash.String()
+	}
+	return r.Name.String()
+}
+
+// Resolve determines what the given reference name refers to on the remote.
+// Full reference names are used as they are, short names are looked up as tag,
+// then as branch and then as commit prefix. A short name that exists both as tag
+// and as branch is ambiguous. Commits that are not the tip of a reference can't be
+// verified without cloning, they are reported as commit with a zero hash.
+func (r *RemoteRefs) Resolve(ref string) (ResolvedRef, error) {
+	if strings.HasPrefix(ref, "refs/") {
+		name := plumbing.ReferenceName(ref)
+		if h, ok := r.Commit(name); ok {
+			kind := RefUnknown
+			switch {
+			case name.IsTag():
+				kind = RefTag
+			case name.IsBranch():
+				kind = RefBranch
+			}
+			return ResolvedRef{Kind: kind, Name: name, Hash: h}, nil
+		}
+		return ResolvedRef{}, r.notFound(ref)
+	}
+
+	tag, branch := plumbing.NewTagReferenceName(ref), plumbing.NewBranchReferenceName(ref)
+	tagHash, isTag := r.Commit(tag)
+	branchHash, isBranch := r.Commit(branch)
+	switch {
+	case isTag && isBranch:
+		return ResolvedRef{}, fmt.Errorf("%w: %s is a tag and a branch in %s, use %s or %s", ErrAmbiguousRef, ref, r.Url, tag, branch)
+	case isTag:
+		return ResolvedRef{Kind: RefTag, Name: tag, Hash: tagHash}, nil
+	case isBranch:
+		return ResolvedRef{Kind: RefBranch, Name: branch, Hash: branchHash}, nil
+	}
+
+	if IsCommit(ref) {
+		resolved := ResolvedRef{Kind: RefCommit, Name: plumbing.ReferenceName(ref)}
+		for name := range r.refs {
+			h, _ := r.Commit(name)
+			if !strings.HasPrefix(h.String(), ref) || h == resolved.Hash {
+				continue
+			}
+			if !resolved.Hash.IsZero() {
+				return ResolvedRef{}, fmt.Errorf("%w: %s matches %s and %s in %s", ErrAmbiguousRef, ref, resolved.Hash, h, r.Url)
+			}
+			resolved.Hash = h
+		}
+		return resolved, nil
+	}
+	return ResolvedRef{}, r.notFound(ref)
+}
+
+func (r *RemoteRefs) notFound(ref string) error {
+	err := fmt.Errorf("reference %s not found in %s", ref, r.Url)
+	if suggestions := r.Suggest(shortRef(ref)); len(suggestions) != 0 {
+		err = fmt.Errorf("%w, did you mean %s?", err, strings.Join(suggestions, ", "))
+	}
+	return err
+}
+
+// shortRef strips the refs/heads/ or refs/tags/ prefix of a reference name.
+func shortRef(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if short, ok := strings.CutPrefix(ref, prefix); ok {
+			return short
+		}
+	}
+	return ref
+}
+
+// Suggest returns up to five tags or branches that are similar to the given name.
+func (r *RemoteRefs) Suggest(ref string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+	maxDistance := max(2, len(ref)/3)
+	var matches []match
+	for _, name := range append(r.Tags(), r.Branches()...) {
+		d := levenshtein(strings.ToLower(ref), strings.ToLower(name))
+		if d <= maxDistance || strings.Contains(name, ref) {
+			matches = append(matches, match{name, d})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return semver.CompareStrings(b.name, a.name)
+	})
+	suggestions := make([]string, 0, 5)
+	for _, m := range matches {
+		if len(suggestions) == 5 {
+			break
+		}
+		suggestions = append(suggestions, m.name)
+	}
+	return suggestions
+}
+
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+// CommitDates returns the commit date of every tag and branch, keyed by short name.
+// Only the commit objects are fetched, this requires the git binary and a
+// remote that supports partial clones. The fetch uses the CA bundle and the
+// pinned certificate like the git backend.
+func (r *RemoteRefs) CommitDates() (map[string]time.Time, error) {
+	path, err := exec.LookPath("git")
+	if err != nil {
+		return nil, errors.New("git not found")
+	}
+	tmp, err := os.MkdirTemp("", "vend-dates")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	gitCmd := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(path, append([]string{"-C", tmp}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		out, err := cmd.Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return nil, err
+		}
+		return out, nil
+	}
+
+	if _, err := gitCmd("init", "--bare", "-q"); err != nil {
+		return nil, err
+	}
+	pins, err := pinnedKeys(r.Url)
+	if err != nil {
+		return nil, err
+	}
+	if err := (systemGit{path: path}).run(nil, nil, append(pins, "-C", tmp, "fetch", "-q", "--depth=1", "--filter=tree:0", r.Url,
+		"+refs/tags/*:refs/tags/*", "+refs/heads/*:refs/heads/*")...); err != nil {
+		return nil, err
+	}
+	out, err := gitCmd("for-each-ref", "--format=%(refname:short)%09%(committerdate:iso-strict)%09%(*committerdate:iso-strict)", "refs/tags", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make(map[string]time.Time)
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		date := fields[1]
+		if fields[2] != "" {
+			// annotated tag, use the date of the tagged commit
+			date = fields[2]
+		}
+		if t, err := time.Parse(time.RFC3339, date); err == nil {
+			dates[fields[0]] = t
+		}
+	}
+	return dates, nil
+}
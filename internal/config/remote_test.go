@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// remoteRefs returns the references of a remote with the given tag and branch names.
+// Every reference points to a different commit.
+func remoteRefs(tags, branches []string) *RemoteRefs {
+	r := &RemoteRefs{
+		Url:    "https://example.com/owner/repo.git",
+		refs:   map[plumbing.ReferenceName]plumbing.Hash{},
+		peeled: map[plumbing.ReferenceName]plumbing.Hash{},
+	}
+	for _, tag := range tags {
+		r.refs[plumbing.NewTagReferenceName(tag)] = plumbing.ComputeHash(plumbing.CommitObject, []byte(tag))
+	}
+	for _, branch := range branches {
+		r.refs[plumbing.NewBranchReferenceName(branch)] = plumbing.ComputeHash(plumbing.CommitObject, []byte(branch))
+	}
+	return r
+}
+
+func TestSuggest(t *testing.T) {
+	refs := remoteRefs([]string{"v1.0.0", "v1.1.0", "v2.0.0"}, []string{"main", "master", "develop"})
+	many := remoteRefs([]string{"v1.0.0", "v1.0.1", "v1.0.2", "v1.0.3", "v1.0.4", "v1.0.5", "v1.0.6"}, nil)
+	tests := []struct {
+		name string
+		refs *RemoteRefs
+		ref  string
+		want []string
+	}{
+		{"typo in branch", refs, "mian", []string{"main"}},
+		{"case insensitive", refs, "MAIN", []string{"main"}},
+		{"closest first, then newest version", refs, "v1.0.O", []string{"v1.0.0", "v2.0.0", "v1.1.0"}},
+		{"substring", refs, "dev", []string{"develop"}},
+		{"nothing similar", refs, "zzz", []string{}},
+		{"at most five", many, "v1.0.x", []string{"v1.0.6", "v1.0.5", "v1.0.4", "v1.0.3", "v1.0.2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.refs.Suggest(tt.ref); !slices.Equal(got, tt.want) {
+				t.Errorf("Suggest(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"main", "mian", 2},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	refs := remoteRefs([]string{"v1.0.0", "both"}, []string{"main", "both"})
+	tag := refs.refs[plumbing.NewTagReferenceName("v1.0.0")]
+	branch := refs.refs[plumbing.NewBranchReferenceName("main")]
+	// An annotated tag resolves to the commit it points to.
+	annotated := plumbing.NewTagReferenceName("v2.0.0")
+	refs.refs[annotated] = plumbing.ComputeHash(plumbing.TagObject, []byte("v2.0.0"))
+	refs.peeled[annotated] = plumbing.ComputeHash(plumbing.CommitObject, []byte("v2.0.0"))
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    ResolvedRef
+		wantErr string
+	}{
+		{"tag", "v1.0.0", ResolvedRef{Kind: RefTag, Name: "refs/tags/v1.0.0", Hash: tag}, ""},
+		{"annotated tag", "v2.0.0", ResolvedRef{Kind: RefTag, Name: annotated, Hash: refs.peeled[annotated]}, ""},
+		{"branch", "main", ResolvedRef{Kind: RefBranch, Name: "refs/heads/main", Hash: branch}, ""},
+		{"full tag name", "refs/tags/both", ResolvedRef{Kind: RefTag, Name: "refs/tags/both", Hash: refs.refs["refs/tags/both"]}, ""},
+		{"full branch name", "refs/heads/both", ResolvedRef{Kind: RefBranch, Name: "refs/heads/both", Hash: refs.refs["refs/heads/both"]}, ""},
+		{"tip commit", branch.String()[:7], ResolvedRef{Kind: RefCommit, Name: plumbing.ReferenceName(branch.String()[:7]), Hash: branch}, ""},
+		{"commit that is no tip", "0000000", ResolvedRef{Kind: RefCommit, Name: "0000000"}, ""},
+		{"tag and branch", "both", ResolvedRef{}, "ambiguous reference"},
+		{"unknown", "mian", ResolvedRef{}, "did you mean main?"},
+		{"unknown full name", "refs/heads/mian", ResolvedRef{}, "did you mean main?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := refs.Resolve(tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+				}
+				if tt.wantErr == "ambiguous reference" && !errors.Is(err, ErrAmbiguousRef) {
+					t.Errorf("Resolve(%q) error = %v, want ErrAmbiguousRef", tt.ref, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.ref, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %+v, want %+v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDest(t *testing.T) {
+	tests := []struct {
+		dest    string
+		wantErr bool
+	}{
+		{"", false},
+		{"vendor/lib", false},
+		{"lib", false},
+		{"vendor/../lib", false},
+		{"../lib", true},
+		{"vendor/../../lib", true},
+		{"/tmp/lib", true},
+		{"..", true},
+	}
+	for _, tt := range tests {
+		err := Source{Url: "https://example.com/owner/repo.git", Dest: tt.dest}.validateDest()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateDest(%q) error = %v, want error %t", tt.dest, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"vend/internal/user"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// branchKeyPrefix marks store entries of branches. Tag names can't contain a tilde,
+// so a branch never shares its store entry with a tag of the same name.
+const branchKeyPrefix = "heads~"
+
+// canonical returns the full reference name of the tag or branch of the source or the full hash of its commit.
+// Short names that haven't been resolved against the remote yet are assumed to be tags.
+func (s Source) canonical() string {
+	if s.canonicalRef != "" && matchesRef(s.ReferenceName, s.canonicalRef) {
+		return s.canonicalRef
+	}
+	if strings.HasPrefix(s.ReferenceName, "refs/") || IsCommit(s.ReferenceName) {
+		return s.ReferenceName
+	}
+	return plumbing.NewTagReferenceName(s.ReferenceName).String()
+}
+
+// matchesRef reports whether the canonical reference is a resolution of the reference name.
+func matchesRef(ref, canonical string) bool {
+	if strings.HasPrefix(canonical, "refs/") {
+		return ref == canonical || (!strings.HasPrefix(ref, "refs/") && plumbing.ReferenceName(canonical).Short() == ref)
+	}
+	return IsCommit(ref) && strings.HasPrefix(canonical, ref)
+}
+
+// refKey is the part of the store entry name that identifies the reference.
+func refKey(canonical string) string {
+	name := plumbing.ReferenceName(canonical)
+	switch {
+	case name.IsTag():
+		return name.Short()
+	case name.IsBranch():
+		return branchKeyPrefix + name.Short()
+	}
+	return canonical
+}
+
+// resolveRefs resolves the references of the sources against their remotes, which determines their store entries.
+// Names that are ambiguous on the remote are rejected. If a remote can't be reached,
+// the reference resolved by the last sync is used. Store entries of older versions of vend are migrated.
+func resolveRefs(sources []*Source) error {
+	var errs []error
+	for _, s := range sources {
+		if err := s.resolveRef(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Url, err))
+			continue
+		}
+		if err := s.migrateEntry(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Source) resolveRef() error {
+	remote, err := ListRemote(s.CanonicalUrl())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v, using %s for %s\n", err, s.canonical(), s.ShortName())
+		return nil
+	}
+	ref, err := remote.Resolve(s.ReferenceName)
+	if err != nil {
+		return err
+	}
+	switch {
+	case ref.Kind != RefCommit || !ref.Hash.IsZero():
+		s.canonicalRef = ref.Canonical()
+	case len(s.ReferenceName) == len(plumbing.ZeroHash.String()):
+		s.canonicalRef = s.ReferenceName
+	case s.canonicalRef != "" && matchesRef(s.ReferenceName, s.canonicalRef):
+		// abbreviated commit that was expanded by an earlier sync
+	default:
+		repo, err := s.FetchRefs(nil, s.ReferenceName)
+		if err != nil {
+			return err
+		}
+		hash, err := mirrorCommit(repo, ref)
+		if err != nil {
+			return err
+		}
+		s.canonicalRef = hash.String()
+	}
+	return nil
+}
+
+// migrateEntry moves the store entry of the source from the path used by older versions of vend,
+// which was named after the reference as written in vend.yaml or after its short name.
+// The old path is kept as a link to the new one for projects that weren't synced since.
+func (s Source) migrateEntry() error {
+	dest := s.DestPath()
+	if fi, err := os.Lstat(dest); err == nil && fi.Mode().Type() != os.ModeSymlink {
+		return nil
+	}
+	unlock, err := s.lockMirror()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if fi, err := os.Lstat(dest); err == nil {
+		if fi.Mode().Type() != os.ModeSymlink {
+			return nil
+		}
+		// a link left by migrating the entry of a branch with the same name as the tag
+		fmt.Fprintf(os.Stderr, "warning: replacing %s, which links to %s, sync the projects still using it\n", dest, s.ShortName())
+		if err := os.Remove(dest); err != nil {
+			return fmt.Errorf("failed to remove link %s: %w", dest, err)
+		}
+	}
+	for _, key := range []string{s.ReferenceName, shortRef(s.ReferenceName)} {
+		legacy := filepath.Join(user.Location(), s.entryName(key))
+		if legacy == dest {
+			continue
+		}
+		if fi, err := os.Lstat(legacy); err != nil || !fi.IsDir() {
+			continue
+		}
+		if !entryMatches(legacy, s.canonical()) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to create store directory: %w", err)
+		}
+		if err := os.Rename(legacy, dest); err != nil {
+			return fmt.Errorf("failed to move store entry %s: %w", legacy, err)
+		}
+		if err := os.Symlink(dest, legacy); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to link %s to %s: %v\n", legacy, dest, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// entryMatches reports whether the store entry at path was checked out from the canonical reference.
+func entryMatches(path, canonical string) bool {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return false
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return false
+	}
+	name := plumbing.ReferenceName(canonical)
+	switch {
+	case name.IsBranch():
+		return head.Name() == name
+	case name.IsTag():
+		_, err := repo.Reference(name, false)
+		return head.Name() == plumbing.HEAD && err == nil
+	case IsCommit(canonical):
+		return head.Name() == plumbing.HEAD && head.Hash().String() == canonical
+	}
+	return false
+}
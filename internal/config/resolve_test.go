@@ -0,0 +1,42 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	const (
+		url  = "https://example.com/owner/repo.git"
+		hash = "4a27656fdc899441678bf30a90fb6b0caa1f2149"
+	)
+	tests := []struct {
+		name      string
+		ref       string
+		canonical string
+		want      string
+	}{
+		{"unresolved tag", "v1.0.0", "", "v1.0.0"},
+		{"resolved tag", "v1.0.0", "refs/tags/v1.0.0", "v1.0.0"},
+		{"full tag name", "refs/tags/v1.0.0", "", "v1.0.0"},
+		{"resolved branch", "main", "refs/heads/main", "heads~main"},
+		{"full branch name", "refs/heads/main", "", "heads~main"},
+		{"branch with slash", "feature/x", "refs/heads/feature/x", "heads~feature/x"},
+		{"other reference", "refs/pull/1/head", "", "refs/pull/1/head"},
+		{"abbreviated commit", "4a27656", hash, hash},
+		{"unresolved abbreviated commit", "4a27656", "", "4a27656"},
+		{"full commit", hash, "", hash},
+		{"stale resolution of another name", "v2.0.0", "refs/heads/main", "v2.0.0"},
+		{"stale resolution of another kind", "refs/tags/main", "refs/heads/main", "main"},
+		{"stale resolution of another commit", "1234567", hash, "1234567"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Source{Url: url, ReferenceName: tt.ref, canonicalRef: tt.canonical}
+			want := filepath.Join("example.com", "owner", "repo", filepath.FromSlash(tt.want))
+			if got := s.Name(); got != want {
+				t.Errorf("Name() = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshSignatureMagic    = "SSHSIG"
+	sshSignatureBegin    = "-----BEGIN SSH SIGNATURE-----"
+	sshSignatureEnd      = "-----END SSH SIGNATURE-----"
+	sshSignatureGitScope = "git"
+)
+
+type (
+	// signedObject is a git object that can carry a signature (commit or annotated tag).
+	signedObject interface {
+		EncodeWithoutSignature(o plumbing.EncodedObject) error
+		Verify(armoredKeyRing string) (*openpgp.Entity, error)
+	}
+
+	sshSignature struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+)
+
+// verifySignature checks that the annotated tag or commit of the given source is
+// signed by one of the allowed keys and returns the fingerprint of the signer.
+func (c *Config) verifySignature(source Source) (string, error) {
+	repo, err := git.PlainOpen(source.DestPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	var (
+		obj       signedObject
+		signature string
+	)
+	if tagRef, err := repo.Tag(strings.TrimPrefix(source.ReferenceName, "refs/tags/")); err == nil {
+		if tag, err := repo.TagObject(tagRef.Hash()); err == nil && tag.PGPSignature != "" {
+			obj, signature = tag, tag.PGPSignature
+		}
+	}
+	if obj == nil {
+		head, err := repo.Head()
+		if err != nil {
+			return "", fmt.Errorf("failed to get head: %w", err)
+		}
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return "", fmt.Errorf("failed to get commit: %w", err)
+		}
+		obj, signature = commit, commit.PGPSignature
+	}
+
+	if signature == "" {
+		return "", fmt.Errorf("%s is not signed", source.ReferenceName)
+	}
+
+	if strings.HasPrefix(signature, sshSignatureBegin) {
+		if c.AllowedSigners == "" {
+			return "", errors.New("found SSH signature but no allowed_signers file is configured")
+		}
+		return verifySSHSignature(obj, signature, c.AllowedSigners)
+	}
+
+	if c.Keyring == "" {
+		return "", errors.New("found PGP signature but no keyring file is configured")
+	}
+	keyring, err := os.ReadFile(c.Keyring)
+	if err != nil {
+		return "", fmt.Errorf("failed to read keyring: %w", err)
+	}
+	entity, err := obj.Verify(string(keyring))
+	if err != nil {
+		return "", fmt.Errorf("invalid PGP signature: %w", err)
+	}
+	return fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint), nil
+}
+
+func verifySSHSignature(obj signedObject, armored string, allowedSignersFile string) (string, error) {
+	allowed, err := readAllowedSigners(allowedSignersFile)
+	if err != nil {
+		return "", err
+	}
+
+	body := strings.TrimSpace(armored)
+	body = strings.TrimPrefix(body, sshSignatureBegin)
+	body = strings.TrimSuffix(body, sshSignatureEnd)
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode SSH signature: %w", err)
+	}
+	if !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return "", errors.New("invalid SSH signature")
+	}
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob[len(sshSignatureMagic):], &sig); err != nil {
+		return "", fmt.Errorf("failed to parse SSH signature: %w", err)
+	}
+	if sig.Namespace != sshSignatureGitScope {
+		return "", fmt.Errorf("SSH signature has unexpected namespace %q", sig.Namespace)
+	}
+
+	pub, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse SSH signature key: %w", err)
+	}
+	fingerprint := ssh.FingerprintSHA256(pub)
+	isAllowed := false
+	for _, a := range allowed {
+		if bytes.Equal(a.Marshal(), pub.Marshal()) {
+			isAllowed = true
+			break
+		}
+	}
+	if !isAllowed {
+		return "", fmt.Errorf("signing key %s is not an allowed signer", fingerprint)
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	encoded := &plumbing.MemoryObject{}
+	if err := obj.EncodeWithoutSignature(encoded); err != nil {
+		return "", err
+	}
+	r, err := encoded.Reader()
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	signed := []byte(sshSignatureMagic)
+	signed = append(signed, ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sig.Namespace, sig.Reserved, sig.HashAlgorithm, h.Sum(nil)})...)
+
+	var s ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &s); err != nil {
+		return "", fmt.Errorf("failed to parse SSH signature blob: %w", err)
+	}
+	if err := pub.Verify(signed, &s); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// readAllowedSigners parses a file in the allowed_signers format of ssh-keygen.
+func readAllowedSigners(file string) ([]ssh.PublicKey, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open allowed signers file: %w", err)
+	}
+	defer f.Close()
+
+	var keys []ssh.PublicKey
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// the first field lists the principals, the rest is in authorized_keys format
+		_, rest, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid line in allowed signers file: %s", line)
+		}
+		keys = append(keys, pub)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read allowed signers file: %w", err)
+	}
+	return keys, nil
+}
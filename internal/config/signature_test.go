@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// The fixtures in testdata/signature are the raw objects of a repository signed by git, with an
+// ed25519 SSH key and an ed25519 PGP key of signer@example.com:
+// unsigned.commit, its child ssh.commit and that one's child pgp.commit; ssh.tag and pgp.tag tag the
+// unsigned commit, unsigned.tag tags the SSH signed commit. tampered.commit is ssh.commit with
+// another message. The other_ files hold the key of other@example.com.
+const (
+	sshSigner = "SHA256:RozybI1JR/XDJlKXixlqhHZxv4yUxSWe4tDB6z7kJGs"
+	pgpSigner = "339BD034482F368C4C99C370547841C1CBA05D7B"
+)
+
+// signedRepo creates the store entry of the source with all fixture objects and their tags,
+// with HEAD detached at the given fixture commit.
+func signedRepo(t *testing.T, source Source, head string) {
+	t.Helper()
+	repo, err := git.PlainInit(source.DestPath(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join("testdata", "signature", "*.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := map[string]plumbing.Hash{}
+	for _, file := range files {
+		name := filepath.Base(file)
+		typ := plumbing.CommitObject
+		switch filepath.Ext(name) {
+		case ".tag":
+			typ = plumbing.TagObject
+		case ".commit":
+		default:
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj := &plumbing.MemoryObject{}
+		obj.SetType(typ)
+		if _, err := obj.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if hashes[name], err = repo.Storer.SetEncodedObject(obj); err != nil {
+			t.Fatal(err)
+		}
+		if typ == plumbing.TagObject {
+			tag := plumbing.NewTagReferenceName("v1-" + strings.TrimSuffix(name, ".tag"))
+			if err := repo.Storer.SetReference(plumbing.NewHashReference(tag, hashes[name])); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hashes[head+".commit"])); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	fixture := func(name string) string {
+		path, err := filepath.Abs(filepath.Join("testdata", "signature", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name           string
+		ref            string
+		head           string
+		allowedSigners string
+		keyring        string
+		want           string
+		wantErr        string
+	}{
+		{"SSH signed tag", "v1-ssh", "unsigned", "allowed_signers", "", sshSigner, ""},
+		{"PGP signed tag", "v1-pgp", "unsigned", "", "keyring.asc", pgpSigner, ""},
+		{"SSH signed commit", "refs/heads/main", "ssh", "allowed_signers", "", sshSigner, ""},
+		{"PGP signed commit", "refs/heads/main", "pgp", "", "keyring.asc", pgpSigner, ""},
+		{"unsigned tag of a signed commit", "v1-unsigned", "ssh", "allowed_signers", "", sshSigner, ""},
+		{"signed tag takes precedence", "v1-ssh", "pgp", "allowed_signers", "", sshSigner, ""},
+		{"untrusted SSH key", "v1-ssh", "unsigned", "other_allowed_signers", "", "", "is not an allowed signer"},
+		{"untrusted PGP key", "refs/heads/main", "pgp", "", "other_keyring.asc", "", "invalid PGP signature"},
+		{"tampered commit", "refs/heads/main", "tampered", "allowed_signers", "", "", "invalid SSH signature"},
+		{"unsigned commit", "refs/heads/main", "unsigned", "allowed_signers", "keyring.asc", "", "is not signed"},
+		{"SSH signature without allowed signers", "v1-ssh", "unsigned", "", "keyring.asc", "", "no allowed_signers file"},
+		{"PGP signature without keyring", "v1-pgp", "unsigned", "allowed_signers", "", "", "no keyring file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_DATA_HOME", t.TempDir())
+			source := Source{Url: "https://example.com/owner/signed.git", ReferenceName: tt.ref}
+			signedRepo(t, source, tt.head)
+			c := &Config{}
+			if tt.allowedSigners != "" {
+				c.AllowedSigners = fixture(tt.allowedSigners)
+			}
+			if tt.keyring != "" {
+				c.Keyring = fixture(tt.keyring)
+			}
+			got, err := c.verifySignature(source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("verifySignature() = %q, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifySignature() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("verifySignature() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
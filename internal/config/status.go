@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+)
+
+type (
+	StoreState int
+	LinkState  int
+
+	// SourceStatus compares a source of the config with the lock, the store and the vendored link.
+	SourceStatus struct {
+		Source Source
+		Locked LockedSource
+		// IsLocked reports whether the lock has an entry for the source with the configured reference.
+		IsLocked bool
+		Store    StoreState
+		Link     LinkState
+	}
+)
+
+const (
+	StoreOk StoreState = iota
+	StoreMissing
+	StoreIncomplete
+	StoreWrongCommit
+	StoreModified
+)
+
+const (
+	LinkOk LinkState = iota
+	LinkMissing
+	LinkNotALink
+	LinkWrongTarget
+)
+
+func (s StoreState) String() string {
+	switch s {
+	case StoreOk:
+		return "ok"
+	case StoreMissing:
+		return "missing"
+	case StoreIncomplete:
+		return "incomplete"
+	case StoreWrongCommit:
+		return "wrong commit"
+	case StoreModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+func (s LinkState) String() string {
+	switch s {
+	case LinkOk:
+		return "ok"
+	case LinkMissing:
+		return "missing"
+	case LinkNotALink:
+		return "not a link"
+	case LinkWrongTarget:
+		return "wrong target"
+	default:
+		return "unknown"
+	}
+}
+
+// InSync reports whether config, lock, store and link agree.
+func (s SourceStatus) InSync() bool {
+	return s.IsLocked && s.Store == StoreOk && s.Link == LinkOk
+}
+
+// Status compares every source with the lock, its store entry and its link.
+// It also returns the entries of the vendored directory that don't belong to any source.
+func (c *Config) Status() ([]SourceStatus, []string, error) {
+	lock, err := c.LoadLock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources, err := c.Resolved()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statuses := make([]SourceStatus, 0, len(sources))
+	links := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		st := SourceStatus{Source: source}
+		st.Locked, st.IsLocked = lock.Find(source)
+		st.IsLocked = st.IsLocked && st.Locked.ReferenceName == source.ReferenceName
+		st.Store = storeState(source, st.Locked)
+		st.Link = linkState(source)
+		statuses = append(statuses, st)
+		links[filepath.Clean(source.LinkPath())] = true
+	}
+
+	var strays []string
+	entries, err := os.ReadDir("vendored")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to read vendored directory: %w", err)
+	}
+	for _, entry := range entries {
+		p := filepath.Join("vendored", entry.Name())
+		if !links[p] {
+			strays = append(strays, p)
+		}
+	}
+	return statuses, strays, nil
+}
+
+func storeState(source Source, locked LockedSource) StoreState {
+	dest := source.DestPath()
+	if _, err := os.Stat(dest); err != nil {
+		return StoreMissing
+	}
+	repo, err := git.PlainOpen(dest)
+	if err != nil {
+		return StoreIncomplete
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return StoreIncomplete
+	}
+	if locked.Commit == "" {
+		return StoreOk
+	}
+	if head.Hash().String() != locked.Commit {
+		return StoreWrongCommit
+	}
+	if locked.ContentHash != "" {
+		hash, err := source.ContentHash()
+		if err != nil || hash != locked.ContentHash {
+			return StoreModified
+		}
+	}
+	return StoreOk
+}
+
+func linkState(source Source) LinkState {
+	link := source.LinkPath()
+	fi, err := os.Lstat(link)
+	if err != nil {
+		return LinkMissing
+	}
+	if fi.Mode().Type() != os.ModeSymlink {
+		return LinkNotALink
+	}
+	target, err := os.Readlink(link)
+	if err != nil || filepath.Clean(target) != filepath.Clean(source.DestPath()) {
+		return LinkWrongTarget
+	}
+	if _, err := os.Stat(link); err != nil {
+		return LinkWrongTarget
+	}
+	return LinkOk
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"vend/internal/blobs"
+	"vend/internal/user"
+
+	"github.com/go-git/go-git/v5"
+)
+
+type Submodule struct {
+	Name       string
+	Path       string
+	Url        string
+	Commit     string
+	Submodules []Submodule
+}
+
+const hashPrefix = "sha256:"
+
+// HashDir computes a hash over all files in dir, ignoring git metadata.
+// The hash only depends on the relative paths, the file modes and the content of the files.
+func HashDir(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", filepath.ToSlash(rel), fi.Mode()&(fs.ModeType|0111))
+		if fi.Mode().Type() == fs.ModeSymlink {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			io.WriteString(h, target)
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", dir, err)
+	}
+	return hashPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ContentHash computes the hash of the store entry of the source.
+func (s Source) ContentHash() (string, error) {
+	return HashDir(s.DestPath())
+}
+
+// Submodules lists the (nested) git submodules of the store entry of the source.
+func (s Source) Submodules() ([]Submodule, error) {
+	repo, err := git.PlainOpen(s.DestPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	return submodules(repo, s.DestPath())
+}
+
+func submodules(repo *git.Repository, dir string) ([]Submodule, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	sms, err := wt.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodules: %w", err)
+	}
+	result := make([]Submodule, 0, len(sms))
+	for _, sm := range sms {
+		cfg := sm.Config()
+		s := Submodule{
+			Name: cfg.Name,
+			Path: filepath.Join(dir, cfg.Path),
+			Url:  cfg.URL,
+		}
+		if status, err := sm.Status(); err == nil {
+			if status.Current.IsZero() {
+				// not downloaded because of the submodule policy of the source
+				continue
+			}
+			s.Commit = status.Current.String()
+		}
+		if smRepo, err := sm.Repository(); err == nil {
+			if nested, err := submodules(smRepo, s.Path); err == nil {
+				s.Submodules = nested
+			}
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// Purge deletes the store entry of the source and the directories that became empty.
+func (s Source) Purge() error {
+	dest := s.DestPath()
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	root := user.Location()
+	for dir := filepath.Dir(dest); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// StoreEntries returns the directories of all checkouts in the store, regardless of the project using them.
+func StoreEntries() ([]string, error) {
+	entries, _, err := scanStore()
+	return entries, err
+}
+
+// StoreMirrors returns the directories of all mirrors in the store.
+func StoreMirrors() ([]string, error) {
+	_, mirrors, err := scanStore()
+	return mirrors, err
+}
+
+func scanStore() (entries, mirrors []string, err error) {
+	err = filepath.WalkDir(user.Location(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == user.Location() {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		switch d.Name() {
+		case mirrorDirName:
+			mirrors = append(mirrors, path)
+			return filepath.SkipDir
+		case blobs.DirName:
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			entries = append(entries, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return entries, mirrors, err
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/goccy/go-yaml"
+)
+
+// SubmodulePolicy controls which submodules of a source are downloaded.
+// It is written as none, recursive, a maximum depth or a mapping with depth, allow and deny.
+type SubmodulePolicy struct {
+	// Depth is the maximum nesting of downloaded submodules, 0 disables submodules.
+	Depth int
+	// Allow lists the globs of submodule paths to download, all if empty.
+	Allow []string
+	// Deny lists the globs of submodule paths to skip.
+	Deny []string
+}
+
+const (
+	submodulesNone      = "none"
+	submodulesRecursive = "recursive"
+)
+
+type submodulePolicyMapping struct {
+	Depth *int     `yaml:"depth,omitempty"`
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+}
+
+func (p *SubmodulePolicy) UnmarshalYAML(data []byte) error {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case string:
+		switch v {
+		case submodulesNone:
+			*p = SubmodulePolicy{Depth: 0}
+		case submodulesRecursive:
+			*p = SubmodulePolicy{Depth: int(git.DefaultSubmoduleRecursionDepth)}
+		default:
+			return fmt.Errorf("invalid submodules value %q, expected none, recursive, a depth or a mapping", v)
+		}
+	case uint64, int64, int:
+		var depth int
+		if err := yaml.Unmarshal(data, &depth); err != nil {
+			return err
+		}
+		if depth < 0 {
+			return fmt.Errorf("invalid submodule depth %d", depth)
+		}
+		*p = SubmodulePolicy{Depth: depth}
+	case map[string]any:
+		var m submodulePolicyMapping
+		if err := yaml.UnmarshalWithOptions(data, &m, yaml.DisallowUnknownField()); err != nil {
+			return err
+		}
+		*p = SubmodulePolicy{Depth: int(git.DefaultSubmoduleRecursionDepth), Allow: m.Allow, Deny: m.Deny}
+		if m.Depth != nil {
+			if *m.Depth < 0 {
+				return fmt.Errorf("invalid submodule depth %d", *m.Depth)
+			}
+			p.Depth = *m.Depth
+		}
+	default:
+		return fmt.Errorf("invalid submodules value, expected none, recursive, a depth or a mapping")
+	}
+	return nil
+}
+
+func (p SubmodulePolicy) MarshalYAML() (any, error) {
+	if len(p.Allow) == 0 && len(p.Deny) == 0 {
+		switch p.Depth {
+		case 0:
+			return submodulesNone, nil
+		case int(git.DefaultSubmoduleRecursionDepth):
+			return submodulesRecursive, nil
+		default:
+			return p.Depth, nil
+		}
+	}
+	return submodulePolicyMapping{Depth: &p.Depth, Allow: p.Allow, Deny: p.Deny}, nil
+}
+
+// includes reports whether the submodule at the path relative to the source is downloaded.
+func (p *SubmodulePolicy) includes(name string) bool {
+	if p == nil {
+		return true
+	}
+	if len(p.Deny) != 0 && MatchPaths(p.Deny, name) {
+		return false
+	}
+	return MatchPaths(p.Allow, name)
+}
+
+func (p *SubmodulePolicy) depth() int {
+	if p == nil {
+		return int(git.DefaultSubmoduleRecursionDepth)
+	}
+	return p.Depth
+}
+
+// key distinguishes the store entries of the same reference with different submodule sets.
+// It is empty for the default of downloading all submodules recursively.
+func (p *SubmodulePolicy) key() string {
+	if p == nil || (p.Depth == int(git.DefaultSubmoduleRecursionDepth) && len(p.Allow) == 0 && len(p.Deny) == 0) {
+		return ""
+	}
+	if p.Depth == 0 {
+		return "nosubmodules"
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s", p.Depth, strings.Join(p.Allow, "\x00"), strings.Join(p.Deny, "\x00"))
+	return "submodules-" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// updateSubmodules initializes and updates the submodules of the repository allowed by the policy of the source.
+func (s Source) updateSubmodules(repo *git.Repository, progress io.Writer) error {
+	return updateSubmodules(repo, s.SubmodulePolicy, "", s.SubmodulePolicy.depth(), progress)
+}
+
+func updateSubmodules(repo *git.Repository, policy *SubmodulePolicy, prefix string, depth int, progress io.Writer) error {
+	if depth <= 0 {
+		return nil
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	sms, err := wt.Submodules()
+	if err != nil {
+		return err
+	}
+	for _, sm := range sms {
+		name := path.Join(prefix, sm.Config().Path)
+		if !policy.includes(name) {
+			continue
+		}
+		if progress != nil {
+			fmt.Fprintf(progress, "Submodule %s\n", name)
+		}
+		if err := sm.Update(&git.SubmoduleUpdateOptions{Init: true}); err != nil {
+			return fmt.Errorf("failed to update submodule %s: %w", name, err)
+		}
+		smRepo, err := sm.Repository()
+		if err != nil {
+			return fmt.Errorf("failed to open submodule %s: %w", name, err)
+		}
+		if err := updateSubmodules(smRepo, policy, name, depth-1, progress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
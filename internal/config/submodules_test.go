@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestSubmodulePolicyYAML(t *testing.T) {
+	type doc struct {
+		Submodules *SubmodulePolicy `yaml:"submodules"`
+	}
+	tests := []struct {
+		name string
+		yaml string
+		want SubmodulePolicy
+		// out is the encoded document if it differs from the input.
+		out string
+	}{
+		{"none", "submodules: none", SubmodulePolicy{Depth: 0}, ""},
+		{"recursive", "submodules: recursive", SubmodulePolicy{Depth: 10}, ""},
+		{"depth", "submodules: 2", SubmodulePolicy{Depth: 2}, ""},
+		{"zero depth", "submodules: 0", SubmodulePolicy{Depth: 0}, "submodules: none"},
+		{"default depth", "submodules: 10", SubmodulePolicy{Depth: 10}, "submodules: recursive"},
+		{"mapping", "submodules:\n  depth: 2\n  allow:\n  - libs/*\n  deny:\n  - libs/big", SubmodulePolicy{Depth: 2, Allow: []string{"libs/*"}, Deny: []string{"libs/big"}}, ""},
+		{"mapping without depth", "submodules:\n  allow:\n  - libs/*", SubmodulePolicy{Depth: 10, Allow: []string{"libs/*"}}, "submodules:\n  depth: 10\n  allow:\n  - libs/*"},
+		{"mapping with depth only", "submodules:\n  depth: 3", SubmodulePolicy{Depth: 3}, "submodules: 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d doc
+			if err := yaml.Unmarshal([]byte(tt.yaml), &d); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if d.Submodules == nil || !reflect.DeepEqual(*d.Submodules, tt.want) {
+				t.Fatalf("Unmarshal() = %+v, want %+v", d.Submodules, tt.want)
+			}
+			out, err := yaml.Marshal(d)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			want := tt.out
+			if want == "" {
+				want = tt.yaml
+			}
+			if got := strings.TrimSuffix(string(out), "\n"); got != want {
+				t.Errorf("Marshal() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSubmodulePolicyInvalidYAML(t *testing.T) {
+	for _, value := range []string{"all", "-1", "[libs]", "\n  depth: -1", "\n  depth: 1\n  include:\n  - libs"} {
+		var d struct {
+			Submodules *SubmodulePolicy `yaml:"submodules"`
+		}
+		if err := yaml.Unmarshal([]byte("submodules: "+value), &d); err == nil {
+			t.Errorf("Unmarshal(%q) = %+v, want error", value, d.Submodules)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"vend/internal/semver"
+)
+
+const (
+	// ConflictHighest resolves conflicting references of a transitive source to the highest version.
+	ConflictHighest = "highest"
+	// ConflictError fails on conflicting references of a transitive source.
+	ConflictError = "error"
+)
+
+// dependency is a source in the resolved graph.
+type dependency struct {
+	source Source
+	// path lists the short names of the sources that lead from the project to this source.
+	path       []string
+	requiredBy []string
+	direct     bool
+}
+
+// errRestart signals that a conflict changed a reference and the graph must be walked again.
+var errRestart = errors.New("restart resolution")
+
+// resolveDependencies merges the sources of the vend.yaml files of all sources into the graph.
+// Sources of the project always win over transitive ones. Conflicting references of
+// transitive sources are resolved according to the conflicts setting of the project.
+func (c *Config) resolveDependencies() ([]*dependency, error) {
+	chosen := make(map[string]string)
+	for {
+		deps, err := c.walkDependencies(chosen)
+		if errors.Is(err, errRestart) {
+			continue
+		}
+		return deps, err
+	}
+}
+
+func (c *Config) walkDependencies(chosen map[string]string) ([]*dependency, error) {
+	nodes := make(map[string]*dependency)
+	var order []*dependency
+	frontier := make([]*dependency, 0, len(c.Sources))
+	for _, source := range c.Sources {
+		dep := &dependency{source: source, path: []string{source.ShortName()}, direct: true}
+		nodes[source.CanonicalUrl()] = dep
+		order = append(order, dep)
+		frontier = append(frontier, dep)
+	}
+
+	for len(frontier) > 0 {
+		var nested []*Source
+		for _, dep := range frontier {
+			if !dep.direct {
+				nested = append(nested, &dep.source)
+			}
+		}
+		if err := resolveRefs(nested); err != nil {
+			return nil, err
+		}
+		if err := c.cloneMissing(frontier); err != nil {
+			return nil, err
+		}
+		var next []*dependency
+		for _, parent := range frontier {
+			nested, err := parent.source.nestedConfig()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", strings.Join(parent.path, " -> "), err)
+			}
+			if nested == nil {
+				continue
+			}
+			for _, source := range nested.Sources {
+				source.Dest = ""
+				url := source.CanonicalUrl()
+				if ref, ok := chosen[url]; ok {
+					source.ReferenceName = ref
+					source.canonicalRef = ""
+				}
+				path := append(slices.Clone(parent.path), source.ShortName())
+
+				existing, ok := nodes[url]
+				if !ok {
+					dep := &dependency{source: source, path: path, requiredBy: []string{parent.source.CanonicalUrl()}}
+					nodes[url] = dep
+					order = append(order, dep)
+					next = append(next, dep)
+					continue
+				}
+				if !slices.Contains(existing.requiredBy, parent.source.CanonicalUrl()) {
+					existing.requiredBy = append(existing.requiredBy, parent.source.CanonicalUrl())
+				}
+				if existing.direct || existing.source.ReferenceName == source.ReferenceName {
+					continue
+				}
+
+				winner, err := c.resolveConflict(existing, source.ReferenceName, path)
+				if err != nil {
+					return nil, err
+				}
+				chosen[url] = winner
+				if winner != existing.source.ReferenceName {
+					return nil, errRestart
+				}
+			}
+		}
+		frontier = next
+	}
+
+	links := make(map[string]string, len(order))
+	for _, dep := range order {
+		link := filepath.Clean(dep.source.LinkPath())
+		if other, ok := links[link]; ok {
+			return nil, fmt.Errorf("%s and %s are both linked to %s, set a name for one of them", other, dep.source.CanonicalUrl(), link)
+		}
+		links[link] = dep.source.CanonicalUrl()
+	}
+	return order, nil
+}
+
+func (c *Config) resolveConflict(existing *dependency, ref string, path []string) (string, error) {
+	conflict := fmt.Errorf("conflicting references for %s: %s (%s) and %s (%s)",
+		existing.source.CanonicalUrl(),
+		existing.source.ReferenceName, strings.Join(existing.path, " -> "),
+		ref, strings.Join(path, " -> "))
+	switch c.Conflicts {
+	case "", ConflictHighest:
+		a, okA := semver.Parse(existing.source.ReferenceName)
+		b, okB := semver.Parse(ref)
+		if !okA || !okB {
+			return "", fmt.Errorf("%w: not both are versions", conflict)
+		}
+		if semver.Compare(b, a) > 0 {
+			return ref, nil
+		}
+		return existing.source.ReferenceName, nil
+	case ConflictError:
+		return "", conflict
+	default:
+		return "", fmt.Errorf("unknown conflict strategy %q", c.Conflicts)
+	}
+}
+
+// nestedConfig reads the vend.yaml of the downloaded source, nil if it has none.
+func (s Source) nestedConfig() (*Config, error) {
+	path := filepath.Join(s.DestPath(), configFileName)
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	return LoadFrom(path)
+}
+
+func (c *Config) cloneMissing(deps []*dependency) error {
+	var missing []Source
+	for _, dep := range deps {
+		if _, err := os.Stat(dep.source.DestPath()); errors.Is(err, os.ErrNotExist) {
+			missing = append(missing, dep.source)
+		}
+	}
+	return c.CloneMultiple(missing)
+}
+
+// Resolved returns the sources of the project followed by the transitive sources of the lock.
+func (c *Config) Resolved() ([]Source, error) {
+	lock, err := c.LoadLock()
+	if err != nil {
+		return slices.Clone(c.Sources), err
+	}
+	return c.resolvedWith(lock), nil
+}
+
+func (c *Config) resolvedWith(lock *Lock) []Source {
+	sources := slices.Clone(c.Sources)
+	for _, locked := range lock.Sources {
+		if !locked.Transitive {
+			continue
+		}
+		source := Source{
+			Url:             locked.Url,
+			ReferenceName:   locked.ReferenceName,
+			LinkName:        locked.Name,
+			SubmodulePolicy: locked.Submodules,
+			Depth:           locked.Depth,
+			LFS:             locked.LFS,
+			Sparse:          locked.Sparse,
+		}
+		source.canonicalUrl = locked.Url
+		source.canonicalRef = locked.CanonicalRef
+		sources = append(sources, source)
+	}
+	return sources
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package doctor
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func freeSpace(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
+
+func foreignOwner(fi os.FileInfo) bool {
+	return false
+}
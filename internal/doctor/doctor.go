@@ -0,0 +1,337 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"vend/internal/config"
+	"vend/internal/settings"
+	"vend/internal/units"
+	"vend/internal/user"
+)
+
+type (
+	// Problem is something that is wrong with the environment.
+	Problem struct {
+		Message string
+		// Fix describes what the user can do about the problem.
+		Fix string
+		// Repair fixes the problem automatically, nil if this can't be done safely.
+		Repair func() error
+		// NeedsSync is set if running vend sync fixes the problem.
+		NeedsSync bool
+	}
+
+	// Result is the outcome of one check.
+	Result struct {
+		Name     string
+		Info     string
+		Problems []Problem
+	}
+)
+
+// minFreeSpace is the free space below which a warning is shown.
+const minFreeSpace = 1 << 30
+
+// supportedConfigVersion is the config version this build of vend understands.
+const supportedConfigVersion = 1
+
+// Run checks the environment for the given project. c may be nil if no project was found.
+func Run(c *config.Config) []Result {
+	results := []Result{
+		checkStore(),
+		checkSymlinks(),
+		checkGit(),
+		checkNetwork(),
+	}
+	if c != nil {
+		// the status hashes every source, it is computed once for both checks
+		statuses, strays, err := c.Status()
+		results = append(results,
+			checkConfig(c),
+			checkSources(c, statuses, err),
+			checkVendored(statuses, strays, err),
+		)
+	}
+	return results
+}
+
+func checkStore() Result {
+	r := Result{Name: "store location", Info: user.Location()}
+	location := user.Location()
+	fi, err := os.Stat(location)
+	if errors.Is(err, os.ErrNotExist) {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("%s does not exist", location),
+			Fix:     "create the directory",
+			Repair:  func() error { return os.MkdirAll(location, 0755) },
+		})
+		return r
+	}
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("can't access %s: %v", location, err),
+			Fix:     "check the permissions of the directory or set XDG_DATA_HOME to another location",
+		})
+		return r
+	}
+	if !fi.IsDir() {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("%s is not a directory", location),
+			Fix:     "remove the file or set XDG_DATA_HOME to another location",
+		})
+		return r
+	}
+
+	f, err := os.CreateTemp(location, ".doctor-*")
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("%s is not writable: %v", location, err),
+			Fix:     fmt.Sprintf("make the directory writable, e.g. sudo chown -R %s %s", currentUserName(), location),
+		})
+	} else {
+		f.Close()
+		_ = os.Remove(f.Name())
+	}
+
+	if free, err := freeSpace(location); err == nil {
+		r.Info += fmt.Sprintf(" (%s free)", units.FormatBytes(free))
+		if free < minFreeSpace {
+			r.Problems = append(r.Problems, Problem{
+				Message: fmt.Sprintf("only %s of free space left", units.FormatBytes(free)),
+				Fix:     "free up disk space, e.g. by removing unused sources with vend remove --purge",
+			})
+		}
+	}
+
+	var foreign []string
+	entries, _ := os.ReadDir(location)
+	for _, entry := range entries {
+		if fi, err := entry.Info(); err == nil && foreignOwner(fi) {
+			foreign = append(foreign, filepath.Join(location, entry.Name()))
+		}
+	}
+	if len(foreign) != 0 {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("owned by another user: %s", strings.Join(foreign, ", ")),
+			Fix:     fmt.Sprintf("sudo chown -R %s %s", currentUserName(), strings.Join(foreign, " ")),
+		})
+	}
+	return r
+}
+
+func checkSymlinks() Result {
+	r := Result{Name: "symlink support"}
+	dir, err := os.MkdirTemp("", "vend-doctor")
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{Message: fmt.Sprintf("can't create temporary directory: %v", err)})
+		return r
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Symlink(dir, filepath.Join(dir, "link")); err != nil {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("can't create symlinks: %v", err),
+			Fix:     "on Windows, enable developer mode or run vend as administrator",
+		})
+	}
+	return r
+}
+
+func checkGit() Result {
+	r := Result{Name: "git"}
+	path, err := exec.LookPath("git")
+	if err != nil {
+		r.Info = "not installed"
+		r.Problems = append(r.Problems, Problem{
+			Message: "git is not installed",
+			Fix:     "install git, it is required for vend init --from-gitsubmodules, commit dates in the reference picker and the git backend",
+		})
+		return r
+	}
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{Message: fmt.Sprintf("%s --version failed: %v", path, err)})
+		return r
+	}
+	r.Info = strings.TrimSpace(string(out))
+	return r
+}
+
+func checkNetwork() Result {
+	r := Result{Name: "network"}
+	var info []string
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+		value := os.Getenv(name)
+		if value == "" {
+			value = os.Getenv(strings.ToLower(name))
+		}
+		if value == "" {
+			continue
+		}
+		info = append(info, name+"="+value)
+		if name == "NO_PROXY" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Host == "" {
+			r.Problems = append(r.Problems, Problem{
+				Message: fmt.Sprintf("%s is not a valid URL: %s", name, value),
+				Fix:     fmt.Sprintf("set %s to a URL like http://proxy.example.com:8080", name),
+			})
+		}
+	}
+	if len(info) == 0 {
+		info = append(info, "no proxy")
+	}
+	r.Info = strings.Join(info, ", ")
+
+	s, err := settings.Get()
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{
+			Message: err.Error(),
+			Fix:     fmt.Sprintf("fix or remove %s", settings.Location()),
+		})
+		return r
+	}
+	if s.CABundle != "" {
+		if _, err := os.Stat(s.CABundle); err != nil {
+			r.Problems = append(r.Problems, Problem{
+				Message: fmt.Sprintf("CA bundle %s: %v", s.CABundle, err),
+				Fix:     fmt.Sprintf("fix ca_bundle in %s", settings.Location()),
+			})
+		}
+	}
+	for host, cert := range s.PinnedCertificates {
+		if _, err := os.Stat(cert); err != nil {
+			r.Problems = append(r.Problems, Problem{
+				Message: fmt.Sprintf("pinned certificate for %s: %v", host, err),
+				Fix:     fmt.Sprintf("fix pinned_certificates in %s", settings.Location()),
+			})
+		}
+	}
+	return r
+}
+
+func checkConfig(c *config.Config) Result {
+	r := Result{Name: "config", Info: c.Location}
+	if c.Version != supportedConfigVersion {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("unsupported config version %d", c.Version),
+			Fix:     fmt.Sprintf("set version to %d or update vend using vend update", supportedConfigVersion),
+		})
+	}
+	if _, err := c.LoadLock(); err != nil {
+		r.Problems = append(r.Problems, Problem{
+			Message: err.Error(),
+			Fix:     "delete vend.lock and run vend sync",
+			Repair: func() error {
+				return os.Remove(c.LockLocation())
+			},
+			NeedsSync: true,
+		})
+	}
+	return r
+}
+
+func checkSources(c *config.Config, statuses []config.SourceStatus, err error) Result {
+	r := Result{Name: "store entries", Info: fmt.Sprintf("%d sources", len(c.Sources))}
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{Message: err.Error()})
+		return r
+	}
+	for _, st := range statuses {
+		source := st.Source
+		switch st.Store {
+		case config.StoreMissing:
+			r.Problems = append(r.Problems, Problem{
+				Message:   fmt.Sprintf("%s is missing", source.DestPath()),
+				Fix:       "run vend sync",
+				NeedsSync: true,
+			})
+		case config.StoreIncomplete:
+			r.Problems = append(r.Problems, Problem{
+				Message:   fmt.Sprintf("%s is an incomplete clone", source.DestPath()),
+				Fix:       "delete it and run vend sync",
+				Repair:    source.Purge,
+				NeedsSync: true,
+			})
+		case config.StoreModified, config.StoreWrongCommit:
+			r.Problems = append(r.Problems, Problem{
+				Message: fmt.Sprintf("%s has %s", source.DestPath(), st.Store),
+				Fix:     "delete it and run vend sync (changes are lost, other projects may use it)",
+			})
+		}
+
+		var foreign bool
+		_ = filepath.WalkDir(source.DestPath(), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if fi, err := d.Info(); err == nil && foreignOwner(fi) {
+				foreign = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if foreign {
+			r.Problems = append(r.Problems, Problem{
+				Message: fmt.Sprintf("%s contains files owned by another user", source.DestPath()),
+				Fix:     fmt.Sprintf("sudo chown -R %s %s", currentUserName(), source.DestPath()),
+			})
+		}
+	}
+	return r
+}
+
+func checkVendored(statuses []config.SourceStatus, strays []string, err error) Result {
+	r := Result{Name: "vendored links"}
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{Message: err.Error()})
+		return r
+	}
+	for _, st := range statuses {
+		link := st.Source.LinkPath()
+		switch st.Link {
+		case config.LinkMissing:
+			r.Problems = append(r.Problems, Problem{
+				Message:   fmt.Sprintf("%s is missing", link),
+				Fix:       "run vend sync",
+				NeedsSync: true,
+			})
+		case config.LinkWrongTarget:
+			message := fmt.Sprintf("%s is dangling or points to the wrong store entry", link)
+			if target, err := os.Readlink(link); err == nil && !strings.HasPrefix(target, user.Location()) {
+				message = fmt.Sprintf("%s points to %s, outside of the store %s (was XDG_DATA_HOME changed?)", link, target, user.Location())
+			}
+			r.Problems = append(r.Problems, Problem{
+				Message:   message,
+				Fix:       "delete the link and run vend sync",
+				Repair:    func() error { return os.Remove(link) },
+				NeedsSync: true,
+			})
+		case config.LinkNotALink:
+			r.Problems = append(r.Problems, Problem{
+				Message: fmt.Sprintf("%s is not a link", link),
+				Fix:     "move it out of the way and run vend sync",
+			})
+		}
+	}
+	for _, stray := range strays {
+		r.Problems = append(r.Problems, Problem{
+			Message: fmt.Sprintf("%s does not belong to any source", stray),
+			Fix:     "run vend sync, it removes everything from vendored that is not a source",
+		})
+	}
+	return r
+}
+
+func currentUserName() string {
+	if user.Current != nil {
+		return user.Current.Username
+	}
+	return "$USER"
+}
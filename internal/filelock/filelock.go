@@ -0,0 +1,70 @@
+// Package filelock serializes access to shared parts of the store between goroutines and vend processes.
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	mu    sync.Mutex
+	locks = make(map[string]*sync.RWMutex)
+)
+
+// Lock acquires an exclusive lock on the given lock file, creating it if needed.
+// The lock is held by the calling goroutine until unlock is called.
+func Lock(path string) (unlock func(), err error) {
+	return acquire(path, true)
+}
+
+// RLock acquires a shared lock on the given lock file, it only excludes holders of the exclusive lock.
+func RLock(path string) (unlock func(), err error) {
+	return acquire(path, false)
+}
+
+func acquire(path string, exclusive bool) (func(), error) {
+	// file locks don't exclude other goroutines of the same process on every platform
+	m := processLock(path)
+	if exclusive {
+		m.Lock()
+	} else {
+		m.RLock()
+	}
+	release := m.Unlock
+	if !exclusive {
+		release = m.RUnlock
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		release()
+		return nil, fmt.Errorf("failed to create directory for lock %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("failed to open lock %s: %w", path, err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		release()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+		release()
+	}, nil
+}
+
+func processLock(path string) *sync.RWMutex {
+	mu.Lock()
+	defer mu.Unlock()
+	m, ok := locks[path]
+	if !ok {
+		m = &sync.RWMutex{}
+		locks[path] = m
+	}
+	return m
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteDot writes the graph in the Graphviz dot language.
+func (g *Graph) WriteDot(w io.Writer) error {
+	fmt.Fprintln(w, "digraph vend {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	for _, n := range g.Nodes {
+		attrs := "shape=box"
+		switch n.Kind {
+		case KindProject:
+			attrs = "shape=box, style=bold"
+		case KindSubmodule:
+			attrs = "shape=box, style=dashed"
+		}
+		fmt.Fprintf(w, "  %s [label=%s, %s];\n", strconv.Quote(n.ID), strconv.Quote(label(n, "\n")), attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "  %s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+func label(n Node, sep string) string {
+	switch {
+	case n.Ref != "":
+		return n.Name + sep + n.Ref
+	case n.Commit != "":
+		return n.Name + sep + n.Commit[:min(12, len(n.Commit))]
+	default:
+		return n.Name
+	}
+}
@@ -0,0 +1,160 @@
+// Package graph builds the dependency graph of a project: its sources,
+// their transitive vend sources and their git submodules.
+package graph
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+	"vend/internal/config"
+)
+
+type Kind string
+
+const (
+	KindProject   Kind = "project"
+	KindSource    Kind = "source"
+	KindSubmodule Kind = "submodule"
+)
+
+type (
+	Node struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Kind   Kind   `json:"kind"`
+		Url    string `json:"url,omitempty"`
+		Ref    string `json:"ref,omitempty"`
+		Commit string `json:"commit,omitempty"`
+	}
+
+	Edge struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	Graph struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+
+		index map[string]int
+	}
+)
+
+// Root is the ID of the project node.
+const Root = "."
+
+// FromConfig builds the graph from the sources and the lock of the project.
+// Submodules are read from the store entries, missing entries are skipped.
+func FromConfig(c *config.Config) (*Graph, error) {
+	sources, err := c.Resolved()
+	if err != nil {
+		return nil, err
+	}
+	lock, err := c.LoadLock()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Graph{index: make(map[string]int)}
+	g.addNode(Node{ID: Root, Name: filepath.Base(filepath.Dir(c.Location)), Kind: KindProject})
+	for _, source := range sources {
+		node := Node{
+			ID:   source.CanonicalUrl(),
+			Name: source.ShortName(),
+			Kind: KindSource,
+			Url:  source.CanonicalUrl(),
+			Ref:  source.ReferenceName,
+		}
+		locked, ok := lock.Find(source)
+		if ok {
+			node.Commit = locked.Commit
+		}
+		g.addNode(node)
+		if !locked.Transitive {
+			g.addEdge(Root, node.ID)
+		}
+		for _, parent := range locked.RequiredBy {
+			g.addEdge(parent, node.ID)
+		}
+		if sms, err := source.Submodules(); err == nil {
+			g.addSubmodules(node.ID, sms)
+		}
+	}
+	return g, nil
+}
+
+func (g *Graph) addSubmodules(parent string, sms []config.Submodule) {
+	for _, sm := range sms {
+		// the same URL may be a source and a submodule or a submodule of several sources
+		id := parent + "#" + sm.Name
+		g.addNode(Node{ID: id, Name: sm.Name, Kind: KindSubmodule, Url: sm.Url, Commit: sm.Commit})
+		g.addEdge(parent, id)
+		g.addSubmodules(id, sm.Submodules)
+	}
+}
+
+func (g *Graph) addNode(n Node) {
+	if _, ok := g.index[n.ID]; ok {
+		return
+	}
+	g.index[n.ID] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, n)
+}
+
+func (g *Graph) addEdge(from, to string) {
+	e := Edge{From: from, To: to}
+	if !slices.Contains(g.Edges, e) {
+		g.Edges = append(g.Edges, e)
+	}
+}
+
+// Node returns the node with the given ID.
+func (g *Graph) Node(id string) (Node, bool) {
+	i, ok := g.index[id]
+	if !ok {
+		return Node{}, false
+	}
+	return g.Nodes[i], true
+}
+
+// Match returns the IDs of the nodes whose ID, URL or name equals s.
+func (g *Graph) Match(s string) []string {
+	var ids []string
+	for _, n := range g.Nodes {
+		if n.Kind != KindProject && (n.ID == s || n.Url == s || n.Name == s) {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids
+}
+
+// Paths lists every path from the project to the node, each starting with the project.
+func (g *Graph) Paths(id string) [][]string {
+	children := make(map[string][]string)
+	for _, e := range g.Edges {
+		children[e.From] = append(children[e.From], e.To)
+	}
+	var paths [][]string
+	var walk func(path []string)
+	walk = func(path []string) {
+		last := path[len(path)-1]
+		if last == id {
+			paths = append(paths, slices.Clone(path))
+			return
+		}
+		for _, child := range children[last] {
+			if !slices.Contains(path, child) {
+				walk(append(path, child))
+			}
+		}
+	}
+	walk([]string{Root})
+	return paths
+}
+
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
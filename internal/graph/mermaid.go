@@ -0,0 +1,31 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMermaid writes the graph as a Mermaid flowchart.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	fmt.Fprintln(w, "graph LR")
+	for i, n := range g.Nodes {
+		text := strings.ReplaceAll(label(n, "<br>"), `"`, "#quot;")
+		if n.Kind == KindProject {
+			fmt.Fprintf(w, "  n%d[[\"%s\"]]\n", i, text)
+		} else {
+			fmt.Fprintf(w, "  n%d[\"%s\"]\n", i, text)
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if to, _ := g.Node(e.To); to.Kind == KindSubmodule {
+			arrow = "-.->"
+		}
+		_, err := fmt.Fprintf(w, "  n%d %s n%d\n", g.index[e.From], arrow, g.index[e.To])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package lfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	mediaType = "application/vnd.git-lfs+json"
+	batchSize = 100
+)
+
+type (
+	batchObject struct {
+		Oid     string                 `json:"oid"`
+		Size    int64                  `json:"size"`
+		Actions map[string]batchAction `json:"actions,omitempty"`
+		Error   *batchError            `json:"error,omitempty"`
+	}
+
+	batchAction struct {
+		Href   string            `json:"href"`
+		Header map[string]string `json:"header,omitempty"`
+	}
+
+	batchError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	batchRequest struct {
+		Operation string        `json:"operation"`
+		Transfers []string      `json:"transfers"`
+		Objects   []batchObject `json:"objects"`
+	}
+
+	batchResponse struct {
+		Objects []batchObject `json:"objects"`
+		Message string        `json:"message,omitempty"`
+	}
+)
+
+// Fetch downloads the objects of the pointers from the endpoint and replaces the pointer files in dir.
+// progress is called after every object.
+func Fetch(client *http.Client, ep Endpoint, dir string, pointers []Pointer, progress func(done, total int)) error {
+	byOid := make(map[string][]Pointer, len(pointers))
+	var objects []batchObject
+	for _, p := range pointers {
+		if _, ok := byOid[p.Oid]; !ok {
+			objects = append(objects, batchObject{Oid: p.Oid, Size: p.Size})
+		}
+		byOid[p.Oid] = append(byOid[p.Oid], p)
+	}
+
+	var errs []error
+	done := 0
+	for start := 0; start < len(objects); start += batchSize {
+		batch := objects[start:min(start+batchSize, len(objects))]
+		resp, err := requestBatch(client, ep, batch)
+		if err != nil {
+			return err
+		}
+		pending := make(map[string]bool, len(batch))
+		for _, obj := range batch {
+			pending[obj.Oid] = true
+		}
+		for _, obj := range resp.Objects {
+			if !pending[obj.Oid] {
+				errs = append(errs, fmt.Errorf("LFS object %s: not requested", obj.Oid))
+				continue
+			}
+			delete(pending, obj.Oid)
+			if err := download(client, obj, dir, byOid[obj.Oid]); err != nil {
+				errs = append(errs, fmt.Errorf("LFS object %s: %w", obj.Oid, err))
+			}
+			done++
+			if progress != nil {
+				progress(done, len(objects))
+			}
+		}
+		for _, obj := range batch {
+			if pending[obj.Oid] {
+				errs = append(errs, fmt.Errorf("LFS object %s: missing from the batch response", obj.Oid))
+				done++
+				if progress != nil {
+					progress(done, len(objects))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func requestBatch(client *http.Client, ep Endpoint, objects []batchObject) (*batchResponse, error) {
+	body, err := json.Marshal(batchRequest{Operation: "download", Transfers: []string{"basic"}, Objects: objects})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(ep.Href, "/")+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range ep.Header {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", mediaType)
+	req.Header.Set("Content-Type", mediaType)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("LFS batch request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	resp := &batchResponse{}
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil && res.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode LFS batch response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LFS batch request failed: %s %s", res.Status, resp.Message)
+	}
+	return resp, nil
+}
+
+// download fetches the object and writes it over all pointer files that refer to it.
+func download(client *http.Client, obj batchObject, dir string, pointers []Pointer) error {
+	if obj.Error != nil {
+		return fmt.Errorf("%d %s", obj.Error.Code, obj.Error.Message)
+	}
+	action, ok := obj.Actions["download"]
+	if !ok || len(pointers) == 0 {
+		return errors.New("no download action")
+	}
+	req, err := http.NewRequest(http.MethodGet, action.Href, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range action.Header {
+		req.Header.Set(k, v)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: %s", res.Status)
+	}
+
+	first := filepath.Join(dir, filepath.FromSlash(pointers[0].Path))
+	tmp, err := os.CreateTemp(filepath.Dir(first), ".lfs-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), res.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if n != pointers[0].Size || hex.EncodeToString(h.Sum(nil)) != obj.Oid {
+		return errors.New("downloaded content doesn't match the pointer")
+	}
+
+	for _, p := range pointers {
+		if err := replace(tmp.Name(), filepath.Join(dir, filepath.FromSlash(p.Path))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replace copies src over the pointer file dst and keeps its mode.
+func replace(src, dst string) error {
+	fi, err := os.Stat(dst)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(filepath.Dir(dst), ".lfs-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(out.Name(), fi.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dst)
+}
@@ -0,0 +1,236 @@
+package lfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// lfsServer serves the batch API and the objects with the given content, keyed by oid.
+// Objects listed in corrupt are served with different content, objects listed in omit
+// are left out of the batch response and objects listed in noAction get no download action.
+type lfsServer struct {
+	objects  map[string]string
+	corrupt  map[string]bool
+	omit     map[string]bool
+	noAction map[string]bool
+	batches  int
+}
+
+func (s *lfsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if oid, ok := strings.CutPrefix(r.URL.Path, "/objects/"); ok && r.Method == http.MethodGet {
+		if r.Header.Get("Authorization") != "Bearer download" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		content := s.objects[oid]
+		if s.corrupt[oid] {
+			content = strings.ToUpper(content)
+		}
+		fmt.Fprint(w, content)
+		return
+	}
+	if r.URL.Path != "/info/lfs/objects/batch" || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	if r.Header.Get("Accept") != mediaType || r.Header.Get("Authorization") != "Basic endpoint" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"credentials needed"}`)
+		return
+	}
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Operation != "download" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	s.batches++
+	resp := batchResponse{}
+	for _, obj := range req.Objects {
+		if s.omit[obj.Oid] {
+			continue
+		}
+		if _, ok := s.objects[obj.Oid]; !ok {
+			obj.Error = &batchError{Code: 404, Message: "object not found"}
+		} else if !s.noAction[obj.Oid] {
+			obj.Actions = map[string]batchAction{"download": {
+				Href:   "http://" + r.Host + "/objects/" + obj.Oid,
+				Header: map[string]string{"Authorization": "Bearer download"},
+			}}
+		}
+		resp.Objects = append(resp.Objects, obj)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func oidOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writePointers creates the pointer files for the given paths and contents in dir.
+func writePointers(t *testing.T, dir string, files map[string]string) []Pointer {
+	t.Helper()
+	var pointers []Pointer
+	for name, content := range files {
+		p := Pointer{Path: name, Oid: oidOf(content), Size: int64(len(content))}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		data := fmt.Sprintf("%s\noid sha256:%s\nsize %d\n", pointerVersion, p.Oid, p.Size)
+		if err := os.WriteFile(path, []byte(data), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		pointers = append(pointers, p)
+	}
+	return pointers
+}
+
+func TestFetch(t *testing.T) {
+	const (
+		image = "image content\n"
+		model = "model content\n"
+	)
+	tests := []struct {
+		name    string
+		files   map[string]string
+		missing string
+		corrupt string
+		omit    string
+		// noAction is the content of the object returned without a download action.
+		noAction string
+		header   string
+		// replaced are the files that must contain their object afterwards.
+		replaced []string
+		wantErr  string
+	}{
+		{
+			name:     "objects",
+			files:    map[string]string{"a.png": image, "assets/b.png": image, "model.bin": model},
+			replaced: []string{"a.png", "assets/b.png", "model.bin"},
+		},
+		{
+			name:     "missing object",
+			files:    map[string]string{"a.png": image, "model.bin": model},
+			missing:  model,
+			replaced: []string{"a.png"},
+			wantErr:  "404 object not found",
+		},
+		{
+			name:     "corrupt object",
+			files:    map[string]string{"a.png": image, "model.bin": model},
+			corrupt:  model,
+			replaced: []string{"a.png"},
+			wantErr:  "doesn't match the pointer",
+		},
+		{
+			name:     "omitted object",
+			files:    map[string]string{"a.png": image, "model.bin": model},
+			omit:     model,
+			replaced: []string{"a.png"},
+			wantErr:  "missing from the batch response",
+		},
+		{
+			name:     "object without download action",
+			files:    map[string]string{"a.png": image, "model.bin": model},
+			noAction: model,
+			replaced: []string{"a.png"},
+			wantErr:  "no download action",
+		},
+		{
+			name:    "rejected batch",
+			files:   map[string]string{"a.png": image},
+			header:  "Basic wrong",
+			wantErr: "401 Unauthorized credentials needed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &lfsServer{objects: map[string]string{}, corrupt: map[string]bool{}, omit: map[string]bool{}, noAction: map[string]bool{}}
+			for _, content := range tt.files {
+				if content != tt.missing {
+					s.objects[oidOf(content)] = content
+				}
+			}
+			if tt.corrupt != "" {
+				s.corrupt[oidOf(tt.corrupt)] = true
+			}
+			if tt.omit != "" {
+				s.omit[oidOf(tt.omit)] = true
+			}
+			if tt.noAction != "" {
+				s.noAction[oidOf(tt.noAction)] = true
+			}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			dir := t.TempDir()
+			pointers := writePointers(t, dir, tt.files)
+			modes := map[string]os.FileMode{}
+			for name := range tt.files {
+				fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				modes[name] = fi.Mode()
+			}
+			header := tt.header
+			if header == "" {
+				header = "Basic endpoint"
+			}
+			ep := Endpoint{Href: srv.URL + "/info/lfs/", Header: map[string]string{"Authorization": header}}
+			var done, total int
+			err := Fetch(srv.Client(), ep, dir, pointers, func(d, n int) { done, total = d, n })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, isPointer := ParsePointer(data)
+				want := slices.Contains(tt.replaced, name)
+				if want && string(data) != content {
+					t.Errorf("%s = %q, want %q", name, data, content)
+				}
+				if !want && !isPointer {
+					t.Errorf("%s = %q, want the pointer file", name, data)
+				}
+				fi, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fi.Mode() != modes[name] {
+					t.Errorf("mode of %s = %v, want %v", name, fi.Mode(), modes[name])
+				}
+			}
+			if s.batches == 0 {
+				return
+			}
+			objects := map[string]bool{}
+			for _, p := range pointers {
+				objects[p.Oid] = true
+			}
+			if done != len(objects) || total != len(objects) {
+				t.Errorf("progress = %d/%d, want %d/%d", done, total, len(objects), len(objects))
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package lfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+// Endpoint is the LFS server of a repository.
+type Endpoint struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+var scpRE = regexp.MustCompile(`^(?:([^@/]+)@)?([^:/]+):(.+)$`)
+
+// ResolveEndpoint finds the LFS server of the repository cloned from remote into dir.
+// The lfs.url of .lfsconfig takes precedence. For SSH remotes, git-lfs-authenticate is asked for
+// the server and credentials, falling back to the HTTPS URL of the same host.
+func ResolveEndpoint(dir, remote string) (Endpoint, error) {
+	if href := lfsConfigUrl(dir); href != "" {
+		return Endpoint{Href: href}, nil
+	}
+
+	if m := scpRE.FindStringSubmatch(remote); m != nil && !strings.Contains(remote, "://") {
+		return sshEndpoint(m[1], m[2], "", m[3]), nil
+	}
+	u, err := url.Parse(remote)
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("failed to parse remote URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return Endpoint{Href: infoLfs(u.String())}, nil
+	case "ssh":
+		return sshEndpoint(u.User.Username(), u.Hostname(), u.Port(), strings.TrimPrefix(u.Path, "/")), nil
+	default:
+		return Endpoint{}, fmt.Errorf("no LFS server known for %s, set lfs.url in .lfsconfig", remote)
+	}
+}
+
+func lfsConfigUrl(dir string) string {
+	f, err := os.Open(filepath.Join(dir, ".lfsconfig"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	cfg := config.New()
+	if err := config.NewDecoder(f).Decode(cfg); err != nil {
+		return ""
+	}
+	return cfg.Section("lfs").Options.Get("url")
+}
+
+func infoLfs(repoUrl string) string {
+	repoUrl = strings.TrimSuffix(repoUrl, "/")
+	if !strings.HasSuffix(repoUrl, ".git") {
+		repoUrl += ".git"
+	}
+	return repoUrl + "/info/lfs"
+}
+
+func sshEndpoint(user, host, port, path string) Endpoint {
+	target := host
+	if user != "" {
+		target = user + "@" + host
+	}
+	args := []string{target}
+	if port != "" {
+		args = append([]string{"-p", port}, args...)
+	}
+	out, err := exec.Command("ssh", append(args, "git-lfs-authenticate", path, "download")...).Output()
+	if err == nil {
+		var ep Endpoint
+		if json.Unmarshal(out, &ep) == nil && ep.Href != "" {
+			return ep
+		}
+	}
+	return Endpoint{Href: infoLfs("https://" + host + "/" + path)}
+}
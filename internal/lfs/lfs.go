@@ -0,0 +1,114 @@
+// Package lfs downloads Git LFS objects into a checked out worktree.
+package lfs
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+)
+
+// Pointer is a file of the worktree that stands in for an LFS object.
+type Pointer struct {
+	// Path is relative to the worktree and uses forward slashes.
+	Path string
+	Oid  string
+	Size int64
+}
+
+const (
+	pointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// maxPointerSize is larger than any valid pointer file.
+	maxPointerSize = 1024
+)
+
+// ParsePointer parses the content of a pointer file.
+func ParsePointer(data []byte) (Pointer, bool) {
+	var p Pointer
+	s := bufio.NewScanner(bytes.NewReader(data))
+	if !s.Scan() || s.Text() != pointerVersion {
+		return p, false
+	}
+	for s.Scan() {
+		key, value, ok := strings.Cut(s.Text(), " ")
+		if !ok {
+			return p, false
+		}
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, "sha256:")
+			if !ok || len(oid) != 64 {
+				return p, false
+			}
+			p.Oid = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return p, false
+			}
+			p.Size = size
+		}
+	}
+	return p, p.Oid != ""
+}
+
+// Scan lists the pointer files of the worktree that have the LFS filter in .gitattributes
+// and are accepted by filter.
+func Scan(dir string, filter func(name string) bool) ([]Pointer, error) {
+	patterns, err := gitattributes.ReadPatterns(osfs.New(dir), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	matcher := gitattributes.NewMatcher(patterns)
+
+	var pointers []Pointer
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		attrs, _ := matcher.Match(strings.Split(name, "/"), []string{"filter"})
+		if attr, ok := attrs["filter"]; !ok || attr.Value() != "lfs" {
+			return nil
+		}
+		if filter != nil && !filter(name) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil || fi.Size() > maxPointerSize {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if p, ok := ParsePointer(data); ok {
+			p.Path = name
+			pointers = append(pointers, p)
+		}
+		return nil
+	})
+	return pointers, err
+}
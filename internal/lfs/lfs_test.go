@@ -0,0 +1,39 @@
+package lfs
+
+import (
+	"strings"
+	"testing"
+)
+
+const testOid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   Pointer
+		wantOk bool
+	}{
+		{"pointer", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testOid + "\nsize 12345\n", Pointer{Oid: testOid, Size: 12345}, true},
+		{"without trailing newline", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testOid + "\nsize 1", Pointer{Oid: testOid, Size: 1}, true},
+		{"unknown keys", "version https://git-lfs.github.com/spec/v1\next-0-foo sha256:abc\noid sha256:" + testOid + "\nsize 0\n", Pointer{Oid: testOid}, true},
+		{"crlf", "version https://git-lfs.github.com/spec/v1\r\noid sha256:" + testOid + "\r\nsize 1\r\n", Pointer{Oid: testOid, Size: 1}, true},
+		{"other version", "version https://hawser.github.com/spec/v1\noid sha256:" + testOid + "\nsize 1\n", Pointer{}, false},
+		{"missing oid", "version https://git-lfs.github.com/spec/v1\nsize 1\n", Pointer{}, false},
+		{"other hash", "version https://git-lfs.github.com/spec/v1\noid sha1:" + testOid[:40] + "\nsize 1\n", Pointer{}, false},
+		{"short oid", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testOid[:63] + "\nsize 1\n", Pointer{}, false},
+		{"negative size", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testOid + "\nsize -1\n", Pointer{}, false},
+		{"invalid size", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testOid + "\nsize big\n", Pointer{}, false},
+		{"line without value", "version https://git-lfs.github.com/spec/v1\noid\n", Pointer{}, false},
+		{"regular file", "package lfs\n", Pointer{}, false},
+		{"empty", "", Pointer{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParsePointer([]byte(tt.data))
+			if ok != tt.wantOk || (ok && got != tt.want) {
+				t.Errorf("ParsePointer(%q) = %+v, %t, want %+v, %t", strings.ReplaceAll(tt.data, "\n", `\n`), got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package license
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type License struct {
+	// File is the path of the license file relative to the scanned directory.
+	File string
+	// ID is the SPDX identifier of the license, empty if it could not be classified.
+	ID string
+}
+
+const Unknown = "NOASSERTION"
+
+var licenseFileRE = regexp.MustCompile(`(?i)^(un)?licen[cs]e|^copying`)
+
+type rule struct {
+	id string
+	// title has to be found at the start of the text. License texts refer to other licenses of the same family,
+	// e.g. the GPL-3.0-only mentions the GNU Affero General Public License, so these are told apart by their title.
+	title string
+	// phrases all have to be found anywhere in the text.
+	phrases []string
+}
+
+// headLength is the part of the normalized text searched for titles, enough for a few copyright lines before the license.
+const headLength = 1000
+
+// rules are checked in order, the first matching rule wins.
+// More specific licenses have to come before the licenses they contain.
+// The full text of a GNU license names a single version, the notices allow any later version.
+var rules = []rule{
+	{id: "AGPL-3.0-only", title: "gnu affero general public license version 3"},
+	{id: "LGPL-3.0-only", title: "gnu lesser general public license version 3"},
+	{id: "LGPL-2.1-only", title: "gnu lesser general public license version 2.1"},
+	{id: "LGPL-2.0-only", title: "gnu library general public license version 2"},
+	{id: "GPL-3.0-only", title: "gnu general public license version 3"},
+	{id: "GPL-2.0-only", title: "gnu general public license version 2"},
+	// license notices instead of the full text
+	{id: "AGPL-3.0-or-later", phrases: []string{"gnu affero general public license as published by the free software foundation either version 3"}},
+	{id: "LGPL-3.0-or-later", phrases: []string{"gnu lesser general public license as published by the free software foundation either version 3"}},
+	{id: "LGPL-2.1-or-later", phrases: []string{"gnu lesser general public license as published by the free software foundation either version 2.1"}},
+	{id: "LGPL-2.0-or-later", phrases: []string{"gnu library general public license as published by the free software foundation either version 2"}},
+	{id: "GPL-3.0-or-later", phrases: []string{"gnu general public license as published by the free software foundation either version 3"}},
+	{id: "GPL-2.0-or-later", phrases: []string{"gnu general public license as published by the free software foundation either version 2"}},
+	{id: "Apache-2.0", title: "apache license version 2.0"},
+	{id: "MPL-2.0", title: "mozilla public license version 2.0"},
+	{id: "BSL-1.0", phrases: []string{"boost software license"}},
+	{id: "Unlicense", phrases: []string{"this is free and unencumbered software released into the public domain"}},
+	{id: "CC0-1.0", phrases: []string{"cc0 1.0 universal"}},
+	{id: "FTL", phrases: []string{"the freetype project license"}},
+	{id: "ISC", phrases: []string{"permission to use copy modify and or distribute this software for any purpose with or without fee"}},
+	{id: "Zlib", phrases: []string{"provided as is without any express or implied warranty", "permission is granted to anyone to use this software for any purpose"}},
+	{id: "BSD-3-Clause", phrases: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{id: "BSD-2-Clause", phrases: []string{"redistribution and use in source and binary forms"}},
+	{id: "MIT", phrases: []string{"permission is hereby granted free of charge"}},
+}
+
+// Classify returns the SPDX identifier for the given license text or Unknown.
+func Classify(text string) string {
+	normalized := normalize(text)
+	head := normalized[:min(len(normalized), headLength)]
+	for _, r := range rules {
+		if r.title != "" && !strings.Contains(head, r.title) {
+			continue
+		}
+		matches := true
+		for _, phrase := range r.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return r.id
+		}
+	}
+	return Unknown
+}
+
+// Matches reports whether the detected license id matches an id of a policy.
+// A GNU license id without -only or -or-later, as used by older SPDX versions, matches both forms.
+func Matches(policyID, id string) bool {
+	if policyID == id {
+		return true
+	}
+	base, ok := strings.CutSuffix(id, "-only")
+	if !ok {
+		base, ok = strings.CutSuffix(id, "-or-later")
+	}
+	return ok && policyID == base
+}
+
+// normalize lowercases the text and replaces punctuation and whitespace by single spaces.
+// Dots are kept for version numbers.
+func normalize(text string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, text)
+	return strings.Join(strings.Fields(mapped), " ")
+}
+
+// Detect looks for license files in the top level of dir and classifies them.
+func Detect(dir string) ([]License, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+	var licenses []License
+	for _, entry := range entries {
+		if entry.IsDir() || !licenseFileRE.MatchString(entry.Name()) {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read license file %s: %w", entry.Name(), err)
+		}
+		licenses = append(licenses, License{
+			File: entry.Name(),
+			ID:   Classify(string(b)),
+		})
+	}
+	return licenses, nil
+}
+
+// Expression combines the identifiers of the given licenses into an SPDX license expression.
+func Expression(licenses []License) string {
+	ids := IDs(licenses)
+	switch len(ids) {
+	case 0:
+		return Unknown
+	case 1:
+		return ids[0]
+	default:
+		return strings.Join(ids, " AND ")
+	}
+}
+
+// IDs returns the sorted, distinct SPDX identifiers of the classified licenses.
+func IDs(licenses []License) []string {
+	seen := make(map[string]bool, len(licenses))
+	ids := make([]string, 0, len(licenses))
+	for _, l := range licenses {
+		if l.ID == Unknown || seen[l.ID] {
+			continue
+		}
+		seen[l.ID] = true
+		ids = append(ids, l.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
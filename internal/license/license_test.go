@@ -0,0 +1,149 @@
+package license
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "GPL-3.0 mentioning the AGPL",
+			text: `                    GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007
+
+  13. Use with the GNU Affero General Public License.
+  ... under version 3 of the GNU Affero General Public License into a single combined work ...`,
+			want: "GPL-3.0-only",
+		},
+		{
+			name: "GPL-2.0 mentioning the LGPL",
+			text: `                    GNU GENERAL PUBLIC LICENSE
+                       Version 2, June 1991
+
+If this is what you want to do, use the GNU Library General Public License instead of this License.`,
+			want: "GPL-2.0-only",
+		},
+		{
+			name: "AGPL-3.0",
+			text: "GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3, 19 November 2007",
+			want: "AGPL-3.0-only",
+		},
+		{
+			name: "LGPL-3.0 referring to the GPL",
+			text: `GNU LESSER GENERAL PUBLIC LICENSE
+Version 3, 29 June 2007
+
+This version of the GNU Lesser General Public License incorporates
+the terms and conditions of version 3 of the GNU General Public License`,
+			want: "LGPL-3.0-only",
+		},
+		{
+			name: "LGPL-2.1",
+			text: "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999",
+			want: "LGPL-2.1-only",
+		},
+		{
+			name: "LGPL-2.0",
+			text: "GNU LIBRARY GENERAL PUBLIC LICENSE\nVersion 2, June 1991",
+			want: "LGPL-2.0-only",
+		},
+		{
+			name: "GPL-3.0 after copyright lines",
+			text: "Copyright (C) 2020 Example\n\nGNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007",
+			want: "GPL-3.0-only",
+		},
+		{
+			name: "GPL-2.0 notice",
+			text: `This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; either version 2 of the License, or
+(at your option) any later version.`,
+			want: "GPL-2.0-or-later",
+		},
+		{
+			name: "LGPL-2.1 notice",
+			text: `This library is free software; you can redistribute it and/or
+modify it under the terms of the GNU Lesser General Public
+License as published by the Free Software Foundation; either
+version 2.1 of the License, or (at your option) any later version.`,
+			want: "LGPL-2.1-or-later",
+		},
+		{
+			name: "GPL-3.0 notice",
+			text: `This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.`,
+			want: "GPL-3.0-or-later",
+		},
+		{
+			name: "Apache-2.0",
+			text: "                                 Apache License\n                           Version 2.0, January 2004",
+			want: "Apache-2.0",
+		},
+		{
+			name: "MIT",
+			text: `Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software")`,
+			want: "MIT",
+		},
+		{
+			name: "Zlib",
+			text: `This software is provided 'as-is', without any express or implied
+warranty. Permission is granted to anyone to use this software for any purpose,`,
+			want: "Zlib",
+		},
+		{
+			name: "ISC",
+			text: "Permission to use, copy, modify, and/or distribute this software for any\npurpose with or without fee is hereby granted",
+			want: "ISC",
+		},
+		{
+			name: "BSD-3-Clause",
+			text: `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+3. Neither the name of the copyright holder nor the names of its contributors`,
+			want: "BSD-3-Clause",
+		},
+		{
+			name: "BSD-2-Clause",
+			text: "Redistribution and use in source and binary forms, with or without\nmodification, are permitted",
+			want: "BSD-2-Clause",
+		},
+		{
+			name: "unknown",
+			text: "All rights reserved.",
+			want: Unknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.text); got != tt.want {
+				t.Errorf("Classify() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		policyID, id string
+		want         bool
+	}{
+		{"MIT", "MIT", true},
+		{"GPL-3.0-only", "GPL-3.0-only", true},
+		{"GPL-3.0", "GPL-3.0-only", true},
+		{"GPL-3.0", "GPL-3.0-or-later", true},
+		{"GPL-3.0-only", "GPL-3.0-or-later", false},
+		{"GPL-3.0", "AGPL-3.0-only", false},
+		{"GPL-2.0", "GPL-3.0-only", false},
+		{"MIT", "MIT-0", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.policyID, tt.id); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %t, want %t", tt.policyID, tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package network
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"vend/internal/settings"
+
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+var (
+	clientOnce sync.Once
+	client     *http.Client
+	clientErr  error
+)
+
+// Client returns the HTTP client shared by every network operation of vend.
+// It honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY as well as the CA bundle and
+// pinned certificates from the global settings.
+// The error is returned by every call, so commands that don't use the network still work with broken settings.
+func Client() (*http.Client, error) {
+	clientOnce.Do(func() {
+		client, clientErr = newClient()
+	})
+	return client, clientErr
+}
+
+// lazyTransport builds the shared client on the first request.
+type lazyTransport struct{}
+
+func (lazyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c, err := Client()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("error setting up network: %w", err)
+	}
+	return c.Transport.RoundTrip(req)
+}
+
+// Setup installs the shared client as transport for go-git.
+func Setup() {
+	c := &http.Client{Transport: lazyTransport{}}
+	gitclient.InstallProtocol("https", githttp.NewClient(c))
+	gitclient.InstallProtocol("http", githttp.NewClient(c))
+}
+
+func newClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	fallback := &http.Client{Transport: transport}
+
+	s, err := settings.Get()
+	if err != nil {
+		return fallback, err
+	}
+
+	if s.CABundle == "" && len(s.PinnedCertificates) == 0 {
+		return fallback, nil
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if s.CABundle != "" {
+		b, err := os.ReadFile(s.CABundle)
+		if err != nil {
+			return fallback, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(b) {
+			return fallback, fmt.Errorf("no certificates found in CA bundle %s", s.CABundle)
+		}
+	}
+
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	if len(s.PinnedCertificates) == 0 {
+		return &http.Client{Transport: transport}, nil
+	}
+
+	pinned := make(map[string]*http.Transport, len(s.PinnedCertificates))
+	for host, certFile := range s.PinnedCertificates {
+		certs, err := readCertificates(certFile)
+		if err != nil {
+			return fallback, fmt.Errorf("failed to read pinned certificate for %s: %w", host, err)
+		}
+		t := transport.Clone()
+		t.TLSClientConfig = pinnedTLSConfig(host, certs)
+		pinned[host] = t
+	}
+	return &http.Client{Transport: &pinningTransport{base: transport, pinned: pinned}}, nil
+}
+
+// pinningTransport sends requests to hosts with a pinned certificate through their own transport,
+// so a certificate pinned for one host is never trusted for another one.
+type pinningTransport struct {
+	base   *http.Transport
+	pinned map[string]*http.Transport
+}
+
+func (t *pinningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if pinned, ok := t.pinned[req.URL.Hostname()]; ok && req.URL.Scheme == "https" {
+		return pinned.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// pinnedTLSConfig only accepts the given certificates, even if they are self-signed.
+func pinnedTLSConfig(host string, certs []*x509.Certificate) *tls.Config {
+	return &tls.Config{
+		// the chain is not verified, the leaf has to be one of the pinned certificates
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) != 0 {
+				leaf := cs.PeerCertificates[0]
+				for _, cert := range certs {
+					if bytes.Equal(cert.Raw, leaf.Raw) {
+						return nil
+					}
+				}
+			}
+			return fmt.Errorf("certificate of %s does not match the pinned certificate", host)
+		},
+	}
+}
+
+func readCertificates(file string) ([]*x509.Certificate, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return certs, nil
+}
+
+// PinnedPublicKeys returns the public keys of the certificates pinned for the host
+// in the format of curl, as used by the http.pinnedPubkey option of git. It is empty if the host has no pin.
+func PinnedPublicKeys(host string) (string, error) {
+	s, err := settings.Get()
+	if err != nil {
+		return "", err
+	}
+	certFile, ok := s.PinnedCertificates[host]
+	if !ok {
+		return "", nil
+	}
+	certs, err := readCertificates(certFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read pinned certificate for %s: %w", host, err)
+	}
+	keys := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		keys = append(keys, "sha256//"+base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	return strings.Join(keys, ";"), nil
+}
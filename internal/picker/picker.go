@@ -0,0 +1,165 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type (
+	// Item is a reference that can be picked.
+	Item struct {
+		Name    string
+		Kind    string
+		Date    time.Time
+		Current bool
+	}
+
+	// DateLoader loads the commit dates of the references, keyed by name.
+	DateLoader func() (map[string]time.Time, error)
+
+	datesMsg struct {
+		dates map[string]time.Time
+		err   error
+	}
+
+	delegate struct{}
+
+	model struct {
+		list      list.Model
+		loadDates DateLoader
+		selected  string
+		quitting  bool
+	}
+)
+
+var (
+	ErrCanceled = errors.New("canceled")
+
+	cursorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
+	currentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true)
+	dimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+)
+
+func (i Item) FilterValue() string {
+	return i.Name
+}
+
+func (d delegate) Height() int                             { return 1 }
+func (d delegate) Spacing() int                            { return 0 }
+func (d delegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d delegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, ok := listItem.(Item)
+	if !ok {
+		return
+	}
+	cursor := "  "
+	if index == m.Index() {
+		cursor = cursorStyle.Render("> ")
+	}
+	name := item.Name
+	if item.Current {
+		name = currentStyle.Render(name + " (current)")
+	} else if index == m.Index() {
+		name = cursorStyle.Render(name)
+	}
+	date := "          "
+	if !item.Date.IsZero() {
+		date = item.Date.Local().Format(time.DateOnly)
+	}
+	fmt.Fprintf(w, "%s%s  %-6s  %s", cursor, dimStyle.Render(date), item.Kind, name)
+}
+
+func (m model) Init() tea.Cmd {
+	if m.loadDates == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		dates, err := m.loadDates()
+		return datesMsg{dates: dates, err: err}
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.list.FilterState() != list.Filtering {
+			switch msg.String() {
+			case "enter":
+				if item, ok := m.list.SelectedItem().(Item); ok {
+					m.selected = item.Name
+				}
+				return m, tea.Quit
+			case "q", "esc", "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			}
+		}
+
+	case datesMsg:
+		if msg.err != nil {
+			return m, m.list.NewStatusMessage(dimStyle.Render("commit dates unavailable: " + msg.err.Error()))
+		}
+		items := m.list.Items()
+		for i, li := range items {
+			item := li.(Item)
+			item.Date = msg.dates[item.Name]
+			items[i] = item
+		}
+		return m, m.list.SetItems(items)
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m model) View() string {
+	if m.quitting || m.selected != "" {
+		return ""
+	}
+	return m.list.View()
+}
+
+// Pick shows an interactive, filterable list of references and returns the name of the selected one.
+// The current item is selected initially. Dates are loaded in the background.
+func Pick(title string, items []Item, loadDates DateLoader) (string, error) {
+	if len(items) == 0 {
+		return "", errors.New("nothing to pick from")
+	}
+	listItems := make([]list.Item, len(items))
+	current := 0
+	for i, item := range items {
+		listItems[i] = item
+		if item.Current {
+			current = i
+		}
+	}
+
+	l := list.New(listItems, delegate{}, 80, 20)
+	l.Title = title
+	l.SetStatusBarItemName("reference", "references")
+	l.Select(current)
+
+	p := tea.NewProgram(model{list: l, loadDates: loadDates}, tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		return "", err
+	}
+	m := final.(model)
+	if m.selected == "" {
+		return "", ErrCanceled
+	}
+	return strings.TrimSpace(m.selected), nil
+}
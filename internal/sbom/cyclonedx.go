@@ -0,0 +1,113 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+	"vend/internal/license"
+	"vend/internal/update"
+)
+
+type (
+	cdxBOM struct {
+		BOMFormat    string         `json:"bomFormat"`
+		SpecVersion  string         `json:"specVersion"`
+		SerialNumber string         `json:"serialNumber"`
+		Version      int            `json:"version"`
+		Metadata     cdxMetadata    `json:"metadata"`
+		Components   []cdxComponent `json:"components"`
+	}
+
+	cdxMetadata struct {
+		Timestamp string       `json:"timestamp"`
+		Tools     cdxTools     `json:"tools"`
+		Component cdxComponent `json:"component"`
+	}
+
+	cdxTools struct {
+		Components []cdxComponent `json:"components"`
+	}
+
+	cdxComponent struct {
+		Type               string           `json:"type"`
+		BOMRef             string           `json:"bom-ref,omitempty"`
+		Name               string           `json:"name"`
+		Version            string           `json:"version,omitempty"`
+		Purl               string           `json:"purl,omitempty"`
+		Hashes             []cdxHash        `json:"hashes,omitempty"`
+		Licenses           []cdxLicense     `json:"licenses,omitempty"`
+		ExternalReferences []cdxExternalRef `json:"externalReferences,omitempty"`
+		Properties         []cdxProperty    `json:"properties,omitempty"`
+		Components         []cdxComponent   `json:"components,omitempty"`
+	}
+
+	cdxHash struct {
+		Alg     string `json:"alg"`
+		Content string `json:"content"`
+	}
+
+	cdxLicense struct {
+		Expression string `json:"expression"`
+	}
+
+	cdxExternalRef struct {
+		Type string `json:"type"`
+		Url  string `json:"url"`
+	}
+
+	cdxProperty struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+)
+
+// WriteCycloneDX writes the document as CycloneDX 1.5 JSON.
+func (d *Document) WriteCycloneDX(w io.Writer) error {
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: cdxTools{
+				Components: []cdxComponent{{Type: "application", Name: "vend", Version: update.Version}},
+			},
+			Component: cdxComponent{Type: "application", Name: d.Name},
+		},
+		Components: cdxComponents(d.Components, ""),
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bom)
+}
+
+func cdxComponents(comps []Component, parentRef string) []cdxComponent {
+	result := make([]cdxComponent, 0, len(comps))
+	for _, c := range comps {
+		ref := c.Name
+		if parentRef != "" {
+			ref = parentRef + "/" + c.Name
+		}
+		cc := cdxComponent{
+			Type:               "library",
+			BOMRef:             ref,
+			Name:               c.Name,
+			Version:            c.Ref,
+			Purl:               c.purl(),
+			ExternalReferences: []cdxExternalRef{{Type: "vcs", Url: c.Url}},
+			Components:         cdxComponents(c.Components, ref),
+		}
+		if c.ContentHash != "" {
+			cc.Hashes = []cdxHash{{Alg: "SHA-256", Content: sha256Hex(c.ContentHash)}}
+		}
+		if c.License != license.Unknown {
+			cc.Licenses = []cdxLicense{{Expression: c.License}}
+		}
+		if c.Commit != "" {
+			cc.Properties = []cdxProperty{{Name: "vend:commit", Value: c.Commit}}
+		}
+		result = append(result, cc)
+	}
+	return result
+}
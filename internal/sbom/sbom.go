@@ -0,0 +1,138 @@
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"vend/internal/config"
+	"vend/internal/license"
+)
+
+type (
+	// Component is a vendored source or one of its submodules.
+	Component struct {
+		Name        string
+		Url         string
+		Ref         string
+		Commit      string
+		ContentHash string
+		License     string
+		Components  []Component
+	}
+
+	// Document is the format independent bill of materials of a project.
+	Document struct {
+		Name       string
+		Components []Component
+	}
+)
+
+// FromConfig collects the components of all sources of the config from the lock and the store.
+func FromConfig(c *config.Config) (*Document, error) {
+	lock, err := c.LoadLock()
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := c.Resolved()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Name:       filepath.Base(filepath.Dir(c.Location)),
+		Components: make([]Component, 0, len(sources)),
+	}
+	for _, source := range sources {
+		comp := Component{
+			Name:    source.ShortName(),
+			Url:     source.CanonicalUrl(),
+			Ref:     source.ReferenceName,
+			License: license.Unknown,
+		}
+		locked, ok := lock.Find(source)
+		if ok {
+			comp.Commit = locked.Commit
+			comp.ContentHash = locked.ContentHash
+		}
+
+		if _, err := os.Stat(source.DestPath()); err != nil {
+			fmt.Fprintf(os.Stderr, "store entry of %s missing, run vend sync to get a complete SBOM\n", source.Url)
+			doc.Components = append(doc.Components, comp)
+			continue
+		}
+		if comp.ContentHash == "" {
+			if comp.ContentHash, err = source.ContentHash(); err != nil {
+				return nil, err
+			}
+		}
+		if licenses, err := license.Detect(source.DestPath()); err == nil {
+			comp.License = license.Expression(licenses)
+		}
+		sms, err := source.Submodules()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get submodules of %s: %w", source.Url, err)
+		}
+		if comp.Components, err = submoduleComponents(sms); err != nil {
+			return nil, err
+		}
+		doc.Components = append(doc.Components, comp)
+	}
+	return doc, nil
+}
+
+func submoduleComponents(sms []config.Submodule) ([]Component, error) {
+	comps := make([]Component, 0, len(sms))
+	for _, sm := range sms {
+		comp := Component{
+			Name:    filepath.Base(sm.Path),
+			Url:     sm.Url,
+			Ref:     sm.Commit,
+			Commit:  sm.Commit,
+			License: license.Unknown,
+		}
+		if _, err := os.Stat(sm.Path); err == nil {
+			hash, err := config.HashDir(sm.Path)
+			if err != nil {
+				return nil, err
+			}
+			comp.ContentHash = hash
+			if licenses, err := license.Detect(sm.Path); err == nil {
+				comp.License = license.Expression(licenses)
+			}
+		}
+		nested, err := submoduleComponents(sm.Submodules)
+		if err != nil {
+			return nil, err
+		}
+		comp.Components = nested
+		comps = append(comps, comp)
+	}
+	return comps, nil
+}
+
+// sha256Hex strips the algorithm prefix of a content hash.
+func sha256Hex(contentHash string) string {
+	return strings.TrimPrefix(contentHash, "sha256:")
+}
+
+// purl returns a package URL for the component.
+func (c Component) purl() string {
+	version := c.Ref
+	if version == "" {
+		version = c.Commit
+	}
+	return fmt.Sprintf("pkg:generic/%s@%s?vcs_url=git+%s", c.Name, strings.ReplaceAll(version, "/", "%2F"), c.Url)
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,123 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"vend/internal/update"
+)
+
+type (
+	spdxDocument struct {
+		SPDXVersion       string             `json:"spdxVersion"`
+		DataLicense       string             `json:"dataLicense"`
+		SPDXID            string             `json:"SPDXID"`
+		Name              string             `json:"name"`
+		DocumentNamespace string             `json:"documentNamespace"`
+		CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+		Packages          []spdxPackage      `json:"packages"`
+		Relationships     []spdxRelationship `json:"relationships"`
+	}
+
+	spdxCreationInfo struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	}
+
+	spdxPackage struct {
+		SPDXID           string            `json:"SPDXID"`
+		Name             string            `json:"name"`
+		VersionInfo      string            `json:"versionInfo,omitempty"`
+		DownloadLocation string            `json:"downloadLocation"`
+		FilesAnalyzed    bool              `json:"filesAnalyzed"`
+		Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+		LicenseConcluded string            `json:"licenseConcluded"`
+		LicenseDeclared  string            `json:"licenseDeclared"`
+		CopyrightText    string            `json:"copyrightText"`
+		ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	}
+
+	spdxChecksum struct {
+		Algorithm     string `json:"algorithm"`
+		ChecksumValue string `json:"checksumValue"`
+	}
+
+	spdxExternalRef struct {
+		ReferenceCategory string `json:"referenceCategory"`
+		ReferenceType     string `json:"referenceType"`
+		ReferenceLocator  string `json:"referenceLocator"`
+	}
+
+	spdxRelationship struct {
+		SPDXElementID      string `json:"spdxElementId"`
+		RelationshipType   string `json:"relationshipType"`
+		RelatedSPDXElement string `json:"relatedSpdxElement"`
+	}
+)
+
+const spdxNoAssertion = "NOASSERTION"
+
+// WriteSPDX writes the document as SPDX 2.3 JSON.
+func (d *Document) WriteSPDX(w io.Writer) error {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              d.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", d.Name, newUUID()),
+		CreationInfo: spdxCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: vend-" + update.Version},
+		},
+		Packages:      []spdxPackage{},
+		Relationships: []spdxRelationship{},
+	}
+	n := 0
+	var add func(comps []Component, parent string)
+	add = func(comps []Component, parent string) {
+		for _, c := range comps {
+			n++
+			id := fmt.Sprintf("SPDXRef-Package-%d", n)
+			pkg := spdxPackage{
+				SPDXID:           id,
+				Name:             c.Name,
+				VersionInfo:      c.Ref,
+				DownloadLocation: spdxNoAssertion,
+				LicenseConcluded: spdxNoAssertion,
+				LicenseDeclared:  c.License,
+				CopyrightText:    spdxNoAssertion,
+				ExternalRefs: []spdxExternalRef{{
+					ReferenceCategory: "PACKAGE-MANAGER",
+					ReferenceType:     "purl",
+					ReferenceLocator:  c.purl(),
+				}},
+			}
+			if c.Url != "" {
+				pkg.DownloadLocation = "git+" + c.Url
+				if c.Commit != "" {
+					pkg.DownloadLocation += "@" + c.Commit
+				}
+			}
+			if c.ContentHash != "" {
+				pkg.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: sha256Hex(c.ContentHash)}}
+			}
+			doc.Packages = append(doc.Packages, pkg)
+			relationship := "CONTAINS"
+			if parent == doc.SPDXID {
+				relationship = "DESCRIBES"
+			}
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID:      parent,
+				RelationshipType:   relationship,
+				RelatedSPDXElement: id,
+			})
+			add(c.Components, id)
+		}
+	}
+	add(d.Components, doc.SPDXID)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,132 @@
+package semver
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version parsed from a tag like "v1.2.3", "1.2" or "release-3.2.10".
+type Version struct {
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+}
+
+var versionRE = regexp.MustCompile(`^([^0-9]*?)v?(\d+)\.(\d+)(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+func Parse(s string) (Version, bool) {
+	s = strings.TrimPrefix(s, "refs/tags/")
+	m := versionRE.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Prefix: m[1], PreRelease: m[5]}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		v.Patch, _ = strconv.Atoi(m[4])
+	}
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 if a is lower, equal or higher than b.
+// The prefix is not compared.
+func Compare(a, b Version) int {
+	if a.Major != b.Major {
+		return cmp(a.Major, b.Major)
+	}
+	if a.Minor != b.Minor {
+		return cmp(a.Minor, b.Minor)
+	}
+	if a.Patch != b.Patch {
+		return cmp(a.Patch, b.Patch)
+	}
+	switch {
+	case a.PreRelease == b.PreRelease:
+		return 0
+	case a.PreRelease == "":
+		return 1
+	case b.PreRelease == "":
+		return -1
+	default:
+		return comparePreRelease(a.PreRelease, b.PreRelease)
+	}
+}
+
+func comparePreRelease(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		ai, aErr := strconv.Atoi(as[i])
+		bi, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if ai != bi {
+				return cmp(ai, bi)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp(len(as), len(bs))
+}
+
+func cmp(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareStrings compares two tags, tags that are no valid versions sort below versions.
+func CompareStrings(a, b string) int {
+	av, aOk := Parse(a)
+	bv, bOk := Parse(b)
+	switch {
+	case aOk && bOk:
+		return Compare(av, bv)
+	case aOk:
+		return 1
+	case bOk:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// SortDescending sorts the tags from the highest to the lowest version.
+func SortDescending(tags []string) {
+	slices.SortStableFunc(tags, func(a, b string) int {
+		return CompareStrings(b, a)
+	})
+}
+
+// Latest returns the highest tag that is a version without pre-release.
+func Latest(tags []string) (string, bool) {
+	latest := ""
+	var latestVersion Version
+	for _, tag := range tags {
+		v, ok := Parse(tag)
+		if !ok || v.PreRelease != "" {
+			continue
+		}
+		if latest == "" || Compare(v, latestVersion) > 0 {
+			latest, latestVersion = tag, v
+		}
+	}
+	return latest, latest != ""
+}
@@ -0,0 +1,64 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"vend/internal/user"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Settings are the global (per user) settings of vend.
+// They are shared by all projects on this machine.
+type Settings struct {
+	// CABundle is the path to a PEM file with additional certificate authorities to trust.
+	CABundle string `yaml:"ca_bundle,omitempty"`
+	// PinnedCertificates maps a host name to the path of a PEM certificate.
+	// Connections to that host are only accepted if the server presents this certificate.
+	PinnedCertificates map[string]string `yaml:"pinned_certificates,omitempty"`
+	// Aliases maps a shorthand prefix to a URL prefix, e.g. "corp" to "https://git.corp/".
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+	// GitBackend selects how sources are cloned, "go-git" (default) or "git". Projects can override it.
+	GitBackend string `yaml:"git_backend,omitempty"`
+}
+
+const settingsFileName = "settings.yaml"
+
+var (
+	loadOnce sync.Once
+	current  *Settings
+	loadErr  error
+)
+
+func Location() string {
+	return filepath.Join(user.Location(), settingsFileName)
+}
+
+// Get returns the global settings.
+// The settings file is read once, a missing file results in empty settings.
+func Get() (*Settings, error) {
+	loadOnce.Do(func() {
+		current, loadErr = load()
+	})
+	return current, loadErr
+}
+
+func load() (*Settings, error) {
+	s := &Settings{}
+	f, err := os.Open(Location())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return s, fmt.Errorf("failed to open settings file: %w", err)
+	}
+	defer f.Close()
+	if err := yaml.NewDecoder(f).Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return s, fmt.Errorf("failed to decode settings file %s: %w", Location(), err)
+	}
+	return s, nil
+}
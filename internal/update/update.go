@@ -13,6 +13,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"vend/internal/network"
 )
 
 type GithubRelease struct {
@@ -21,8 +22,12 @@ type GithubRelease struct {
 
 func Update(force bool) error {
 	// 1. Get the latest release JSON from GitHub
+	client, err := network.Client()
+	if err != nil {
+		return fmt.Errorf("error setting up network: %w", err)
+	}
 	releaseURL := "https://api.github.com/repos/tsukinoko-kun/vend/releases/latest"
-	resp, err := http.Get(releaseURL)
+	resp, err := client.Get(releaseURL)
 	if err != nil {
 		return fmt.Errorf("failed to get latest release info: %w", err)
 	}
@@ -69,7 +74,7 @@ func Update(force bool) error {
 	log.Printf("Downloading update from: %s", downloadURL)
 
 	// 3. Download the tar.gz file.
-	resp, err = http.Get(downloadURL)
+	resp, err = client.Get(downloadURL)
 	if err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}